@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ava-labs/avash/node"
 	"github.com/ava-labs/avash/utils/logging"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -22,11 +23,15 @@ import (
 type Configuration struct {
 	AvalancheLocation, DataDir string
 	Log                        logging.Log
+	Profiles                   map[string]node.ConsensusProfile
+	Binaries                   map[string]string
 }
 
 type configFile struct {
 	AvalancheLocation, DataDir string
 	Log                        configFileLog
+	Profiles                   map[string]node.ConsensusProfile
+	Binaries                   map[string]string
 }
 
 type configFileLog struct {
@@ -113,6 +118,24 @@ func InitConfig(cfgpath string) {
 		os.Exit(1)
 	}
 
+	// Named binaries without a location default to `avalancheLocation`
+	for name, binary := range config.Binaries {
+		if binary == "" {
+			binary = config.AvalancheLocation
+		}
+		config.Binaries[name] = binary
+		if _, err := os.Stat(binary); err != nil {
+			fmt.Printf("Invalid avalanchego binary location for '%s': %s\n", name, binary)
+		}
+	}
+
+	for name, profile := range config.Profiles {
+		if err := profile.Validate(); err != nil {
+			fmt.Printf("Invalid consensus profile '%s': %s\n", name, err.Error())
+			os.Exit(1)
+		}
+	}
+
 	// Configure and create log
 	logCfg := makeLogConfig(config.Log, config.DataDir)
 	log, err := logging.New(logCfg)
@@ -125,6 +148,8 @@ func InitConfig(cfgpath string) {
 		AvalancheLocation: config.AvalancheLocation,
 		DataDir:           config.DataDir,
 		Log:               *log,
+		Profiles:          config.Profiles,
+		Binaries:          config.Binaries,
 	}
 	Config.Log.Info("Config file set: %s", viper.ConfigFileUsed())
 	Config.Log.Info("Avash successfully configured.")
@@ -0,0 +1,88 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"github.com/ava-labs/avash/cfg"
+	"github.com/spf13/cobra"
+)
+
+// AdminCmd represents the admin command
+var AdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Calls the admin API of a node.",
+	Long: `Calls the admin API of the node given by --node, defaulting to the first
+	running node. The node must be started with --api-admin-enabled.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// AdminAliasChainCmd gives a chain an alias
+var AdminAliasChainCmd = &cobra.Command{
+	Use:   "alias-chain [chain] [alias]",
+	Short: "Gives a chain an alias on a node.",
+	Long: `Gives a chain an alias on a node, which can then be used in place of the
+	chain ID in its API endpoints.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		adminCall("admin.aliasChain", struct {
+			Chain string `json:"chain"`
+			Alias string `json:"alias"`
+		}{args[0], args[1]}, "chain "+args[0]+" aliased to "+args[1])
+	},
+}
+
+// AdminMemoryProfileCmd writes a memory profile
+var AdminMemoryProfileCmd = &cobra.Command{
+	Use:   "memory-profile",
+	Short: "Writes a memory profile of a node.",
+	Long:  `Writes a memory profile of a node to mem.profile in its working directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		adminCall("admin.memoryProfile", struct{}{}, "memory profile written")
+	},
+}
+
+// AdminLockProfileCmd writes a lock profile
+var AdminLockProfileCmd = &cobra.Command{
+	Use:   "lock-profile",
+	Short: "Writes a lock profile of a node.",
+	Long: `Writes a mutex contention profile of a node to lock.profile in its working
+	directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		adminCall("admin.lockProfile", struct{}{}, "lock profile written")
+	},
+}
+
+// adminCall calls `method` of the admin API, logging `done` on success
+func adminCall(method string, params interface{}, done string) {
+	log := cfg.Config.Log
+	name, err := defaultNode(apiOpts.node)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	var reply struct {
+		Success bool `json:"success"`
+	}
+	if err := apiClient.Call(name, "ext/admin", method, params, &reply); err != nil {
+		log.Error(err.Error())
+		return
+	}
+	log.Info("%s: %s", name, done)
+	saveResult(apiOpts.save, reply)
+}
+
+func init() {
+	addAPIFlags(AdminCmd)
+	AdminCmd.AddCommand(AdminAliasChainCmd)
+	AdminCmd.AddCommand(AdminMemoryProfileCmd)
+	AdminCmd.AddCommand(AdminLockProfileCmd)
+}
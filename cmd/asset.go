@@ -0,0 +1,200 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/wallet"
+	"github.com/spf13/cobra"
+)
+
+// assetStore is the variable store recording the IDs of created assets by
+// symbol
+const assetStore = "assets"
+
+var assetOpts struct {
+	assetType    string
+	denomination uint8
+	supply       uint64
+	holder       string
+	minters      string
+	threshold    uint32
+	wallet       string
+	timeout      time.Duration
+}
+
+// AVAXWalletAssetCmd represents the avaxwallet asset command
+var AVAXWalletAssetCmd = &cobra.Command{
+	Use:   "asset",
+	Short: "Tools for managing X-chain assets.",
+	Long:  `Tools for managing X-chain assets.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// AVAXWalletAssetCreateCmd creates a new X-chain asset
+var AVAXWalletAssetCreateCmd = &cobra.Command{
+	Use:   "create [node name] [name] [symbol] --wallet [wallet name]",
+	Short: "Creates an X-chain asset.",
+	Long: `Creates an X-chain asset paid for by a wallet, whose keys are imported into
+	an avash keystore user on the node. --type selects the kind of asset:
+
+	fixed     a fungible asset whose whole --supply goes to --holder
+	variable  a fungible asset minted by --minters, with an optional initial
+	          --supply going to --holder
+	nft       a non-fungible asset minted by --minters
+
+	--holder and --minters default to the wallet's first address. Once the
+	transaction is accepted the asset ID is saved to "assets".[symbol], so the
+	symbol can be given to balance --asset.`,
+	Example: `avaxwallet asset create n1 "Test Token" TST --type fixed --supply 1000000 --denomination 2 --wallet w`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAssetOpts(cmd)
+		if len(args) < 3 || assetOpts.wallet == "" {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name, assetName, symbol := args[0], args[1], args[2]
+		w, err := lookupWallet(assetOpts.wallet)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		keys, err := walletKeys(w)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		fromAddrs, err := importKeys(name, "ext/bc/X", "avm.importKey", keys)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		holder := assetOpts.holder
+		if holder == "" {
+			holder = fromAddrs[0]
+		}
+		minters := []string{holder}
+		if assetOpts.minters != "" {
+			minters = strings.Split(assetOpts.minters, ",")
+		}
+		header := api.JSONSpendHeader{
+			UserPass:      avashUser,
+			JSONFromAddrs: api.JSONFromAddrs{From: fromAddrs},
+		}
+		minterSets := []avm.Owners{{
+			Threshold: avajson.Uint32(assetOpts.threshold),
+			Minters:   minters,
+		}}
+
+		var reply avm.AssetIDChangeAddr
+		switch assetOpts.assetType {
+		case "fixed", "variable":
+			args := avm.CreateAssetArgs{
+				JSONSpendHeader: header,
+				Name:            assetName,
+				Symbol:          symbol,
+				Denomination:    assetOpts.denomination,
+			}
+			if assetOpts.supply > 0 {
+				args.InitialHolders = []*avm.Holder{{
+					Amount:  avajson.Uint64(assetOpts.supply),
+					Address: holder,
+				}}
+			}
+			if assetOpts.assetType == "variable" {
+				args.MinterSets = minterSets
+			} else if assetOpts.supply == 0 {
+				log.Error("a fixed cap asset needs a --supply")
+				return
+			}
+			err = apiClient.Call(name, "ext/bc/X", "avm.createAsset", args, &reply)
+		case "nft":
+			err = apiClient.Call(name, "ext/bc/X", "avm.createNFTAsset", avm.CreateNFTAssetArgs{
+				JSONSpendHeader: header,
+				Name:            assetName,
+				Symbol:          symbol,
+				MinterSets:      minterSets,
+			}, &reply)
+		default:
+			log.Error("unknown asset type %s, expected fixed, variable or nft", assetOpts.assetType)
+			return
+		}
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("AssetID: %s", reply.AssetID)
+		if err := waitForTx(name, "X", reply.AssetID, assetOpts.timeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		recordVar(assetStore, symbol, reply.AssetID.String())
+		log.Info("Asset ID saved to %q.%q", assetStore, symbol)
+	},
+}
+
+// resolveAsset returns the ID saved for the asset symbol `s`, or else `s`
+// itself, which the node resolves as an asset ID or alias
+func resolveAsset(s string) string {
+	if store, err := AvashVars.Get(assetStore); err == nil {
+		if v, err := store.Get(s); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// walletKeys returns the private keys of `w` in their "PrivateKey-" form
+func walletKeys(w *wallet.Wallet) ([]string, error) {
+	if len(w.Keys()) == 0 {
+		return nil, fmt.Errorf("wallet has no keys: %s", w.Name)
+	}
+	var keys []string
+	for _, sk := range w.Keys() {
+		str, err := formatting.EncodeWithChecksum(defaultEncoding, sk.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("could not encode private key: %s", err.Error())
+		}
+		keys = append(keys, "PrivateKey-"+str)
+	}
+	return keys, nil
+}
+
+func resetAssetOpts(cmd *cobra.Command) {
+	assetOpts.assetType = "fixed"
+	assetOpts.denomination = 0
+	assetOpts.supply = 0
+	assetOpts.holder = ""
+	assetOpts.minters = ""
+	assetOpts.threshold = 1
+	assetOpts.wallet = ""
+	assetOpts.timeout = defaultTxTimeout
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetAssetOpts(AVAXWalletAssetCreateCmd)
+	AVAXWalletAssetCreateCmd.Flags().StringVar(&assetOpts.assetType, "type", assetOpts.assetType, "Kind of asset: fixed, variable or nft.")
+	AVAXWalletAssetCreateCmd.Flags().Uint8Var(&assetOpts.denomination, "denomination", assetOpts.denomination, "Number of decimal places of a fungible asset.")
+	AVAXWalletAssetCreateCmd.Flags().Uint64Var(&assetOpts.supply, "supply", assetOpts.supply, "Initial supply of a fungible asset, in base units.")
+	AVAXWalletAssetCreateCmd.Flags().StringVar(&assetOpts.holder, "holder", assetOpts.holder, "X-chain address receiving the initial supply.")
+	AVAXWalletAssetCreateCmd.Flags().StringVar(&assetOpts.minters, "minters", assetOpts.minters, "Comma separated X-chain addresses allowed to mint the asset.")
+	AVAXWalletAssetCreateCmd.Flags().Uint32Var(&assetOpts.threshold, "threshold", assetOpts.threshold, "Number of minters needed to sign a mint.")
+	AVAXWalletAssetCreateCmd.Flags().StringVar(&assetOpts.wallet, "wallet", assetOpts.wallet, "Wallet paying the asset creation fee.")
+	AVAXWalletAssetCreateCmd.Flags().DurationVar(&assetOpts.timeout, "timeout", assetOpts.timeout, "How long to wait for the transaction to be accepted.")
+
+	AVAXWalletAssetCmd.AddCommand(AVAXWalletAssetCreateCmd)
+	AVAXWalletCmd.AddCommand(AVAXWalletAssetCmd)
+}
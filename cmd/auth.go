@@ -0,0 +1,181 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/nodeapi"
+	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/spf13/cobra"
+)
+
+// tokenLock guards authTokens, which concurrent requests may fill in
+var tokenLock sync.Mutex
+
+// AuthCmd represents the auth command
+var AuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Tools for managing the API auth tokens of nodes.",
+	Long: `Tools for managing the API auth tokens of nodes. Requests to a node started
+	with --api-auth-required carry a token minted with the password in its
+	--api-auth-password-file the first time it's needed. The token is reused for
+	every later request until it's refreshed or revoked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// AuthTokenCmd prints the auth token of a node
+var AuthTokenCmd = &cobra.Command{
+	Use:   "token [node name]",
+	Short: "Prints the auth token used for a node.",
+	Long:  `Prints the auth token used for a node, minting one if there's none yet.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		token, err := nodeToken(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if token == "" {
+			log.Info("%s doesn't require an auth token", args[0])
+			return
+		}
+		log.Info("Token: %s", token)
+	},
+}
+
+// AuthRefreshCmd replaces the auth token of a node
+var AuthRefreshCmd = &cobra.Command{
+	Use:   "refresh [node name]",
+	Short: "Replaces the auth token used for a node.",
+	Long: `Revokes the auth token used for a node, if any, and mints a new one from
+	its password file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		if err := revokeToken(args[0]); err != nil {
+			log.Warn(err.Error())
+		}
+		token, err := mintToken(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Token: %s", token)
+	},
+}
+
+// AuthRevokeCmd revokes the auth token of a node
+var AuthRevokeCmd = &cobra.Command{
+	Use:   "revoke [node name]",
+	Short: "Revokes the auth token used for a node.",
+	Long: `Revokes the auth token used for a node and forgets it. A new token is minted
+	by the next request to the node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		if err := revokeToken(args[0]); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("token revoked: %s", args[0])
+	},
+}
+
+// nodeToken returns the auth token for the named node, minting one if the node
+// requires it and there's none yet, or "" if the node doesn't require one
+func nodeToken(name string) (string, error) {
+	tokenLock.Lock()
+	token, ok := authTokens[name]
+	tokenLock.Unlock()
+	if ok {
+		return token, nil
+	}
+	flags, err := pmgr.ProcManager.NodeFlags(name)
+	if err != nil || !flags.APIAuthRequired {
+		return "", nil
+	}
+	return mintToken(name)
+}
+
+// mintToken mints an auth token for all endpoints of the named node, using the
+// password in its password file, and caches it
+func mintToken(name string) (string, error) {
+	password, err := authPassword(name)
+	if err != nil {
+		return "", err
+	}
+	var reply struct {
+		Token string `json:"token"`
+	}
+	err = apiClient.Call(name, nodeapi.AuthEndpoint, "auth.newToken", struct {
+		Password  string   `json:"password"`
+		Endpoints []string `json:"endpoints"`
+	}{password, []string{"*"}}, &reply)
+	if err != nil {
+		return "", err
+	}
+	tokenLock.Lock()
+	authTokens[name] = reply.Token
+	tokenLock.Unlock()
+	return reply.Token, nil
+}
+
+// revokeToken revokes the cached auth token of the named node and forgets it
+func revokeToken(name string) error {
+	tokenLock.Lock()
+	token, ok := authTokens[name]
+	delete(authTokens, name)
+	tokenLock.Unlock()
+	if !ok {
+		return fmt.Errorf("no auth token for %s", name)
+	}
+	password, err := authPassword(name)
+	if err != nil {
+		return err
+	}
+	return apiClient.Call(name, nodeapi.AuthEndpoint, "auth.revokeToken", struct {
+		Password string `json:"password"`
+		Token    string `json:"token"`
+	}{password, token}, nil)
+}
+
+// authPassword reads the API auth password of the named node from the
+// password file it was started with
+func authPassword(name string) (string, error) {
+	flags, err := pmgr.ProcManager.NodeFlags(name)
+	if err != nil {
+		return "", err
+	}
+	if flags.APIAuthPasswordFileKey == "" {
+		return "", fmt.Errorf("%s has no --api-auth-password-file", name)
+	}
+	b, err := ioutil.ReadFile(flags.APIAuthPasswordFileKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to read auth password of %s: %s", name, err.Error())
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func init() {
+	AuthCmd.AddCommand(AuthTokenCmd)
+	AuthCmd.AddCommand(AuthRefreshCmd)
+	AuthCmd.AddCommand(AuthRevokeCmd)
+}
@@ -6,15 +6,22 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/crypto"
 	"github.com/ava-labs/avalanchego/utils/formatting"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/avm"
 	"github.com/ava-labs/avash/cfg"
-	"github.com/ava-labs/avash/node"
-	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/ava-labs/avash/wallet"
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-
-	"github.com/ybbus/jsonrpc"
 )
 
 // AVAXWalletCmd represents the avaxwallet command
@@ -22,7 +29,9 @@ var AVAXWalletCmd = &cobra.Command{
 	Use:   "avaxwallet",
 	Short: "Tools for interacting with AVAX Payments over the network.",
 	Long: `Tools for interacting with AVAX Payments over the network. Using this 
-	command you can send, and get the status of a transaction.`,
+	command you can send, and get the status of a transaction. Named wallets
+	hold private keys and track their X-chain UTXOs, building and signing
+	transactions locally for send.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -30,8 +39,37 @@ var AVAXWalletCmd = &cobra.Command{
 
 const (
 	defaultEncoding = formatting.CB58
+
+	// utxoPageSize is the number of UTXOs fetched per avm.getUTXOs call
+	utxoPageSize = 1024
+)
+
+// AvashWallets holds the wallets created in this shell, keyed by name
+var AvashWallets = make(map[string]*wallet.Wallet)
+
+var (
+	walletNetworkID string
+	balanceAsset    string
+	makeTxAsset     string
 )
 
+var waitOpts struct {
+	wait    bool
+	timeout time.Duration
+}
+
+var transferOpts struct {
+	from    string
+	to      string
+	amount  uint64
+	wallet  string
+	node    string
+	timeout time.Duration
+}
+
+// weiPerNAVAX is the number of C-chain wei in one nAVAX
+var weiPerNAVAX = big.NewInt(1000000000)
+
 // AVAXWalletNewKeyCmd creates a new private key
 var AVAXWalletNewKeyCmd = &cobra.Command{
 	Use:   "newkey",
@@ -57,46 +95,29 @@ var AVAXWalletNewKeyCmd = &cobra.Command{
 var AVAXWalletSendCmd = &cobra.Command{
 	Use:   "send [node name] [tx string]",
 	Short: "Sends a transaction to a node.",
-	Long:  `Sends a transaction to a node.`,
+	Long: `Sends a transaction to a node. With --wait, waits for the transaction to
+	be decided and saves its receipt, as tx wait does.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) >= 2 {
-			log := cfg.Config.Log
-			if meta, err := pmgr.ProcManager.Metadata(args[0]); err == nil {
-				var md node.Metadata
-				metaBytes := []byte(meta)
-				if err := json.Unmarshal(metaBytes, &md); err == nil {
-					jrpcloc := fmt.Sprintf("http://%s:%s/ext/bc/avm", md.Serverhost, md.HTTPport)
-					rpcClient := jsonrpc.NewClient(jrpcloc)
-					response, err := rpcClient.Call("avm.issueTx", struct {
-						Tx string
-					}{
-						Tx: args[1],
-					})
-					if err != nil {
-						log.Error("error sent tx: %s", args[1])
-						log.Error("rpcClient returned error: %s", err.Error())
-					} else if response.Error != nil {
-						log.Error("error sent tx: %s", args[1])
-						log.Error("rpcClient returned error: %d, %s", response.Error.Code, response.Error.Message)
-					} else {
-						var s struct {
-							TxID string
-						}
-						err = response.GetObject(&s)
-						if err != nil {
-							log.Error("error on parsing response: %s", err.Error())
-						} else {
-							log.Info("TxID:%s", s.TxID)
-						}
-					}
-				} else {
-					log.Error("unable to unmarshal metadata for node %s: %s", args[0], err.Error())
-				}
-			} else {
-				log.Error("node not found: %s", args[0])
-			}
-		} else {
+		defer resetWaitOpts(cmd)
+		if len(args) < 2 {
 			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		issued := time.Now()
+		var reply api.JSONTxID
+		err := apiClient.Call(args[0], "ext/bc/X", "avm.issueTx", api.FormattedTx{
+			Tx:       args[1],
+			Encoding: defaultEncoding,
+		}, &reply)
+		if err != nil {
+			log.Error("error sent tx: %s", args[1])
+			log.Error(err.Error())
+			return
+		}
+		log.Info("TxID:%s", reply.TxID)
+		if waitOpts.wait {
+			reportReceipt(args[0], reply.TxID.String(), issued, waitOpts.timeout)
 		}
 	},
 }
@@ -107,112 +128,683 @@ var AVAXWalletStatusCmd = &cobra.Command{
 	Short: "Checks the status of a transaction on a node.",
 	Long:  `Checks the status of a transaction on a node.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) >= 2 {
-			log := cfg.Config.Log
-			if meta, err := pmgr.ProcManager.Metadata(args[0]); err == nil {
-				var md node.Metadata
-				metaBytes := []byte(meta)
-				if err := json.Unmarshal(metaBytes, &md); err == nil {
-					jrpcloc := fmt.Sprintf("http://%s:%s/ext/bc/avm", md.Serverhost, md.HTTPport)
-					rpcClient := jsonrpc.NewClient(jrpcloc)
-					response, err := rpcClient.Call("avm.getTxStatus", struct {
-						TxID string
-					}{
-						TxID: args[1],
-					})
-					if err != nil {
-						log.Error("error sent txid: %s", args[1])
-						log.Error("rpcClient returned error: %s", err.Error())
-					} else if response.Error != nil {
-						log.Error("error sent txid: %s", args[1])
-						log.Error("rpcClient returned error: %d, %s", response.Error.Code, response.Error.Message)
-					} else {
-						var s struct {
-							Status string
-						}
-						err = response.GetObject(&s)
-						if err != nil {
-							log.Error("error on parsing response: %s", err.Error())
-						} else {
-							log.Info("Status:%s", s.Status)
-						}
-					}
-				} else {
-					log.Error("unable to unmarshal metadata for node %s: %s", args[0], err.Error())
-				}
-			} else {
-				log.Error("node not found: %s", args[0])
-			}
-		} else {
+		if len(args) < 2 {
 			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		var reply struct {
+			Status string `json:"status"`
+		}
+		err := apiClient.Call(args[0], "ext/bc/X", "avm.getTxStatus", struct {
+			TxID string `json:"txID"`
+		}{args[1]}, &reply)
+		if err != nil {
+			log.Error("error sent txid: %s", args[1])
+			log.Error(err.Error())
+			return
 		}
+		log.Info("Status:%s", reply.Status)
 	},
 }
 
 // AVAXWalletGetBalanceCmd will get the balance of an address from a node
 var AVAXWalletGetBalanceCmd = &cobra.Command{
-	Use:   "balance [node name] [address]",
+	Use:   "balance [node name] [address] --asset [id|alias|all]",
 	Short: "Checks the balance of an address from a node.",
-	Long:  `Checks the balance of an address from a node.`,
+	Long: `Checks the balance of an address from a node. --asset takes an asset ID, an
+	alias known to the node, a symbol saved by asset create, or "all" to list
+	every asset the address holds. Amounts are shown both in base units, as
+	"Balance: [amount]", and scaled by the asset's denomination.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) >= 2 {
-			log := cfg.Config.Log
-			if meta, err := pmgr.ProcManager.Metadata(args[0]); err == nil {
-				var md node.Metadata
-				metaBytes := []byte(meta)
-				if err := json.Unmarshal(metaBytes, &md); err == nil {
-					jrpcloc := fmt.Sprintf("http://%s:%s/ext/bc/avm", md.Serverhost, md.HTTPport)
-					rpcClient := jsonrpc.NewClient(jrpcloc)
-					response, err := rpcClient.Call("avm.getBalance", struct {
-						Address string
-						AssetID string
-					}{
-						Address: args[1],
-						AssetID: "AVAX",
-					})
-					if err != nil {
-						log.Error("error sent address: %s", args[1])
-						log.Error("rpcClient returned error: %s", err.Error())
-					} else if response.Error != nil {
-						log.Error("error sent address: %s", args[1])
-						log.Error("rpcClient returned error: %d, %s", response.Error.Code, response.Error.Message)
-					} else {
-						var s struct {
-							Balance string
-						}
-						err = response.GetObject(&s)
-						if err != nil {
-							log.Error("error on parsing response: %s", err.Error())
-						} else {
-							log.Info("Balance: %s", s.Balance)
-						}
-					}
-				} else {
-					log.Error("unable to unmarshal metadata for node %s: %s", args[0], err.Error())
-				}
-			} else {
-				log.Error("node not found: %s", args[0])
+		defer func() {
+			balanceAsset = "AVAX"
+			resetChangedFlags(cmd)
+		}()
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name, addr := args[0], args[1]
+		if strings.ToLower(balanceAsset) != "all" {
+			assetID := resolveAsset(balanceAsset)
+			var reply struct {
+				Balance avajson.Uint64 `json:"balance"`
 			}
-		} else {
+			if err := apiClient.Call(name, "ext/bc/X", "avm.getBalance", struct {
+				Address string `json:"address"`
+				AssetID string `json:"assetID"`
+			}{addr, assetID}, &reply); err != nil {
+				log.Error(err.Error())
+				return
+			}
+			log.Info("Balance: %d", reply.Balance)
+			desc, err := assetDescription(name, assetID)
+			if err != nil {
+				log.Warn("could not describe asset %s: %s", balanceAsset, err.Error())
+				return
+			}
+			log.Info("Amount: %s %s", formatDenomination(uint64(reply.Balance), uint8(desc.Denomination)), desc.Symbol)
+			return
+		}
+		var reply struct {
+			Balances []struct {
+				AssetID string         `json:"asset"`
+				Balance avajson.Uint64 `json:"balance"`
+			} `json:"balances"`
+		}
+		if err := apiClient.Call(name, "ext/bc/X", "avm.getAllBalances", api.JSONAddress{Address: addr}, &reply); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Asset ID", "Name", "Symbol", "Denomination", "Balance", "Amount"})
+		table.SetBorder(false)
+		for _, b := range reply.Balances {
+			desc, err := assetDescription(name, b.AssetID)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			table.Append([]string{
+				desc.AssetID.String(),
+				desc.Name,
+				desc.Symbol,
+				fmt.Sprint(uint8(desc.Denomination)),
+				fmt.Sprint(uint64(b.Balance)),
+				formatDenomination(uint64(b.Balance), uint8(desc.Denomination)),
+			})
+		}
+		table.Render()
+	},
+}
+
+// walletAssetID returns the ID of the asset `s` names for `w`: an asset ID, a
+// symbol saved by asset create or AVAX
+func walletAssetID(w *wallet.Wallet, s string) (ids.ID, error) {
+	if strings.ToUpper(s) == "AVAX" {
+		return w.Chain.AVAXAssetID, nil
+	}
+	assetID, err := ids.FromString(resolveAsset(s))
+	if err != nil {
+		return ids.Empty, fmt.Errorf("unknown asset %s: expected an asset ID, a saved symbol or AVAX", s)
+	}
+	return assetID, nil
+}
+
+// assetDescription fetches the name, symbol and denomination of `assetID`
+// from the named node
+func assetDescription(name string, assetID string) (avm.GetAssetDescriptionReply, error) {
+	var reply avm.GetAssetDescriptionReply
+	err := apiClient.Call(name, "ext/bc/X", "avm.getAssetDescription", struct {
+		AssetID string `json:"assetID"`
+	}{assetID}, &reply)
+	return reply, err
+}
+
+// formatDenomination formats `amount` base units of an asset with
+// `denomination` decimal places
+func formatDenomination(amount uint64, denomination uint8) string {
+	return formatUnits(strconv.FormatUint(amount, 10), int(denomination))
+}
+
+// formatUnits places a decimal point `d` digits from the end of the decimal
+// integer `s`
+func formatUnits(s string, d int) string {
+	if d == 0 {
+		return s
+	}
+	if len(s) <= d {
+		s = strings.Repeat("0", d-len(s)+1) + s
+	}
+	return s[:len(s)-d] + "." + s[len(s)-d:]
+}
+
+// AVAXWalletCreateCmd creates a new wallet
+var AVAXWalletCreateCmd = &cobra.Command{
+	Use:   "create [wallet name]",
+	Short: "Creates an empty wallet.",
+	Long: `Creates an empty wallet for the network given by --network-id. Add keys
+	to it with addkey, then refresh it from a node to fetch its UTXOs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer func() {
+			walletNetworkID = constants.LocalName
+			resetChangedFlags(cmd)
+		}()
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name := args[0]
+		if _, ok := AvashWallets[name]; ok {
+			log.Error("wallet already exists: %s", name)
+			return
+		}
+		networkID, err := constants.NetworkID(walletNetworkID)
+		if err != nil {
+			log.Error("invalid network ID %s: %s", walletNetworkID, err.Error())
+			return
+		}
+		w, err := wallet.New(name, networkID)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		AvashWallets[name] = w
+		log.Info("wallet created: %s", name)
+	},
+}
+
+// AVAXWalletAddKeyCmd adds a private key to a wallet
+var AVAXWalletAddKeyCmd = &cobra.Command{
+	Use:   "addkey [wallet name] [private key]",
+	Short: "Adds a private key to a wallet.",
+	Long: `Adds a private key to a wallet, printing the X-chain address it controls.
+	Refresh the wallet afterwards to pick up the UTXOs of the new address.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		w, err := lookupWallet(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		sk, err := wallet.ParsePrivateKey(args[1])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		addr, err := w.AddKey(sk)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Address: %s", addr)
+	},
+}
+
+// AVAXWalletRefreshCmd fetches the UTXOs of a wallet from a node
+var AVAXWalletRefreshCmd = &cobra.Command{
+	Use:   "refresh [node name] [wallet name]",
+	Short: "Fetches the UTXOs of a wallet from a node.",
+	Long: `Fetches the X-chain ID, AVAX asset ID, transaction fee and the UTXOs
+	controlled by the keys of a wallet from a node. Pending transactions made
+	with maketx are forgotten.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
 			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		w, err := lookupWallet(args[1])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := refreshWallet(args[0], w); err != nil {
+			log.Error(err.Error())
+			return
 		}
+		log.Info("wallet refreshed: %s", w.Name)
 	},
 }
 
+// AVAXWalletMakeTxCmd builds and signs an asset transfer
+var AVAXWalletMakeTxCmd = &cobra.Command{
+	Use:   "maketx [wallet name] [destination address] [amount] --asset [id|alias]",
+	Short: "Builds and signs an asset transfer from a wallet.",
+	Long: `Builds and signs a transaction sending an amount of an asset, in base
+	units, from a wallet to an X-chain address, printing the transaction string
+	to give to send. --asset takes an asset ID, a symbol saved by asset create
+	or AVAX, the default. The fee is always paid in AVAX. The UTXOs it spends
+	are held by the wallet until the transaction is removed or the wallet is
+	refreshed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer func() {
+			makeTxAsset = "AVAX"
+			resetChangedFlags(cmd)
+		}()
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		w, err := lookupWallet(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		to, err := w.ParseAddress(args[1])
+		if err != nil {
+			log.Error("invalid destination address %s: %s", args[1], err.Error())
+			return
+		}
+		amount, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil || amount == 0 {
+			log.Error("invalid amount: %s", args[2])
+			return
+		}
+		assetID, err := walletAssetID(w, makeTxAsset)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		tx, err := w.CreateTx(assetID, amount, to)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		str, err := formatting.EncodeWithChecksum(defaultEncoding, tx.Bytes())
+		if err != nil {
+			log.Error("could not encode transaction: %s", err.Error())
+			return
+		}
+		log.Info("TxID:%s", tx.ID())
+		log.Info("Tx:%s", str)
+	},
+}
+
+// AVAXWalletRemoveCmd forgets a pending transaction of a wallet
+var AVAXWalletRemoveCmd = &cobra.Command{
+	Use:   "remove [wallet name] [tx string]",
+	Short: "Removes a pending transaction from a wallet.",
+	Long: `Removes a transaction made with maketx from a wallet, making the UTXOs
+	it spent available again. Use this for transactions that were never sent or
+	were rejected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		w, err := lookupWallet(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		b, err := formatting.Decode(defaultEncoding, args[1])
+		if err != nil {
+			log.Error("invalid transaction string: %s", err.Error())
+			return
+		}
+		tx, err := w.ParseTx(b)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := w.RemoveTx(tx); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("transaction removed: %s", tx.ID())
+	},
+}
+
+// AVAXWalletTransferCmd moves AVAX between the chains of the primary network
+var AVAXWalletTransferCmd = &cobra.Command{
+	Use:   "transfer --from [chain] --to [chain] --amount [nAVAX] --wallet [wallet name]",
+	Short: "Moves AVAX of a wallet between the X, P and C chains.",
+	Long: `Moves AVAX of a wallet between the X, P and C chains. The keys of the wallet
+	are imported into an avash keystore user on the node, which issues the export
+	from the source chain and, once it's accepted, the matching import into the
+	destination chain. The funds arrive at the wallet's first key. When both are
+	accepted the wallet's balances on the two chains are printed.`,
+	Example: `avaxwallet transfer --from X --to P --amount 1000000000 --wallet w --node n1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetTransferOpts(cmd)
+		if transferOpts.from == "" || transferOpts.to == "" || transferOpts.amount == 0 || transferOpts.wallet == "" {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		from, to := strings.ToUpper(transferOpts.from), strings.ToUpper(transferOpts.to)
+		source, err := lookupChain(from)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		dest, err := lookupChain(to)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if from == to {
+			log.Error("source and destination chains are both %s", from)
+			return
+		}
+		w, err := lookupWallet(transferOpts.wallet)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		name, err := defaultNode(transferOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		keys, err := walletKeys(w)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		for _, chain := range []primaryChain{source, dest} {
+			if _, err := importKeys(name, chain.endpoint, chain.prefix+".importKey", keys); err != nil {
+				log.Error(err.Error())
+				return
+			}
+		}
+		owner := w.Keys()[0].PublicKey()
+		exportTo, err := w.FormatChainAddress(to, owner.Address())
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		importTo := exportTo
+		if to == "C" {
+			importTo = wallet.EthAddress(owner)
+		}
+
+		var exportReply api.JSONTxID
+		err = apiClient.Call(name, source.endpoint, source.exportMethod, transferArgs{
+			UserPass: avashUser,
+			To:       exportTo,
+			Amount:   avajson.Uint64(transferOpts.amount),
+			AssetID:  "AVAX",
+		}, &exportReply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Export TxID:%s", exportReply.TxID)
+		if err := waitForTx(name, from, exportReply.TxID, transferOpts.timeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var importReply api.JSONTxID
+		err = apiClient.Call(name, dest.endpoint, dest.importMethod, transferArgs{
+			UserPass:    avashUser,
+			SourceChain: from,
+			To:          importTo,
+		}, &importReply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Import TxID:%s", importReply.TxID)
+		if err := waitForTx(name, to, importReply.TxID, transferOpts.timeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		for _, alias := range []string{from, to} {
+			balance, err := chainBalance(name, alias, w)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			log.Info("%s-chain balance: %d nAVAX", alias, balance)
+		}
+	},
+}
+
+// transferArgs are the arguments of the export and import calls of the
+// primary network chains
+type transferArgs struct {
+	api.UserPass
+	SourceChain string         `json:"sourceChain,omitempty"`
+	To          string         `json:"to"`
+	Amount      avajson.Uint64 `json:"amount,omitempty"`
+	AssetID     string         `json:"assetID,omitempty"`
+}
+
+// chainBalance returns the AVAX held by the keys of `w` on the primary network
+// chain `alias`, in nAVAX, as reported by the named node
+func chainBalance(name string, alias string, w *wallet.Wallet) (uint64, error) {
+	var total uint64
+	for _, sk := range w.Keys() {
+		pk := sk.PublicKey()
+		addr, err := w.FormatChainAddress(alias, pk.Address())
+		if err != nil {
+			return 0, err
+		}
+		switch alias {
+		case "X":
+			var reply struct {
+				Balance avajson.Uint64 `json:"balance"`
+			}
+			if err := apiClient.Call(name, "ext/bc/X", "avm.getBalance", struct {
+				Address string `json:"address"`
+				AssetID string `json:"assetID"`
+			}{addr, "AVAX"}, &reply); err != nil {
+				return 0, err
+			}
+			total += uint64(reply.Balance)
+		case "P":
+			var reply struct {
+				Balance avajson.Uint64 `json:"balance"`
+			}
+			if err := apiClient.Call(name, "ext/P", "platform.getBalance", api.JSONAddress{Address: addr}, &reply); err != nil {
+				return 0, err
+			}
+			total += uint64(reply.Balance)
+		case "C":
+			var reply string
+			if err := apiClient.Call(name, "ext/bc/C/rpc", "eth_getBalance", []string{wallet.EthAddress(pk), "latest"}, &reply); err != nil {
+				return 0, err
+			}
+			wei, ok := new(big.Int).SetString(strings.TrimPrefix(reply, "0x"), 16)
+			if !ok {
+				return 0, fmt.Errorf("invalid C-chain balance: %s", reply)
+			}
+			total += new(big.Int).Div(wei, weiPerNAVAX).Uint64()
+		default:
+			return 0, fmt.Errorf("unknown chain %s, expected X, P or C", alias)
+		}
+	}
+	return total, nil
+}
+
+func resetTransferOpts(cmd *cobra.Command) {
+	transferOpts.from = ""
+	transferOpts.to = ""
+	transferOpts.amount = 0
+	transferOpts.wallet = ""
+	transferOpts.node = ""
+	transferOpts.timeout = defaultTxTimeout
+	resetChangedFlags(cmd)
+}
+
+// receiptStore is the variable store recording the receipts of transactions
+// by ID
+const receiptStore = "receipts"
+
+// txReceipt describes the outcome of an X-chain transaction
+type txReceipt struct {
+	TxID      string `json:"txID"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latencyMs"`
+	Fee       uint64 `json:"fee"`
+}
+
+// reportReceipt waits for the X-chain transaction `txID` to be decided by the
+// named node, then logs and saves its receipt
+func reportReceipt(name string, txID string, start time.Time, timeout time.Duration) {
+	log := cfg.Config.Log
+	id, err := ids.FromString(txID)
+	if err != nil {
+		log.Error("invalid transaction ID %s: %s", txID, err.Error())
+		return
+	}
+	receipt, err := awaitReceipt(name, id, start, timeout)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	b, err := json.Marshal(receipt)
+	if err != nil {
+		log.Error("could not encode receipt: %s", err.Error())
+		return
+	}
+	recordVar(receiptStore, receipt.TxID, string(b))
+	log.Info("Status:%s", receipt.Status)
+	log.Info("Latency: %s, Fee: %d nAVAX", time.Duration(receipt.LatencyMS)*time.Millisecond, receipt.Fee)
+	log.Info("Receipt saved to %q.%q", receiptStore, receipt.TxID)
+}
+
+// awaitReceipt waits for the X-chain transaction `txID` to be decided by the
+// named node, returning its receipt with the latency measured from `start`
+func awaitReceipt(name string, txID ids.ID, start time.Time, timeout time.Duration) (txReceipt, error) {
+	status, err := pollTxStatus(name, "X", txID, timeout, pollInterval)
+	if err != nil {
+		return txReceipt{}, err
+	}
+	receipt := txReceipt{
+		TxID:      txID.String(),
+		Status:    status,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if status != "Accepted" {
+		return receipt, nil
+	}
+	var reply api.FormattedTx
+	err = apiClient.Call(name, "ext/bc/X", "avm.getTx", api.GetTxArgs{
+		TxID:     txID,
+		Encoding: formatting.Hex,
+	}, &reply)
+	if err != nil {
+		return receipt, err
+	}
+	b, err := formatting.Decode(reply.Encoding, reply.Tx)
+	if err != nil {
+		return receipt, fmt.Errorf("invalid transaction: %s", err.Error())
+	}
+	tx, err := wallet.ParseTx(b)
+	if err != nil {
+		return receipt, err
+	}
+	avax, err := assetDescription(name, "AVAX")
+	if err != nil {
+		return receipt, err
+	}
+	receipt.Fee = wallet.Fee(tx, avax.AssetID)
+	return receipt, nil
+}
+
+func resetWaitOpts(cmd *cobra.Command) {
+	waitOpts.wait = false
+	waitOpts.timeout = defaultTxTimeout
+	resetChangedFlags(cmd)
+}
+
+// lookupWallet returns the wallet with the given name
+func lookupWallet(name string) (*wallet.Wallet, error) {
+	w, ok := AvashWallets[name]
+	if !ok {
+		return nil, fmt.Errorf("wallet not found: %s", name)
+	}
+	return w, nil
+}
+
+// refreshWallet fetches the X-chain parameters and the UTXOs of `w` from the
+// named node
+func refreshWallet(name string, w *wallet.Wallet) error {
+	var chain struct {
+		BlockchainID ids.ID `json:"blockchainID"`
+	}
+	if err := apiClient.Call(name, "ext/info", "info.getBlockchainID", struct {
+		Alias string `json:"alias"`
+	}{w.Chain.Alias}, &chain); err != nil {
+		return err
+	}
+	var asset struct {
+		AssetID ids.ID `json:"assetID"`
+	}
+	if err := apiClient.Call(name, "ext/bc/X", "avm.getAssetDescription", struct {
+		AssetID string `json:"assetID"`
+	}{"AVAX"}, &asset); err != nil {
+		return err
+	}
+	var fees struct {
+		TxFee avajson.Uint64 `json:"txFee"`
+	}
+	if err := apiClient.Call(name, "ext/info", "info.getTxFee", struct{}{}, &fees); err != nil {
+		return err
+	}
+	w.Chain.ID = chain.BlockchainID
+	w.Chain.AVAXAssetID = asset.AssetID
+	w.Chain.TxFee = uint64(fees.TxFee)
+
+	var utxos [][]byte
+	addrs := w.Addresses()
+	var startIndex api.Index
+	for len(addrs) > 0 {
+		var reply api.GetUTXOsReply
+		err := apiClient.Call(name, "ext/bc/X", "avm.getUTXOs", api.GetUTXOsArgs{
+			Addresses:  addrs,
+			Limit:      utxoPageSize,
+			StartIndex: startIndex,
+			Encoding:   formatting.Hex,
+		}, &reply)
+		if err != nil {
+			return err
+		}
+		for _, s := range reply.UTXOs {
+			b, err := formatting.Decode(reply.Encoding, s)
+			if err != nil {
+				return fmt.Errorf("invalid UTXO: %s", err.Error())
+			}
+			utxos = append(utxos, b)
+		}
+		if reply.NumFetched < utxoPageSize {
+			break
+		}
+		startIndex = reply.EndIndex
+	}
+	return w.SetUTXOs(utxos)
+}
+
 /*
 avaxwallet
 	create [wallet name] -> "wallet created: " + [wallet name]
 	addkey [wallet name] [private key] -> address
-	balance [node name] [address] -> uint
+	balance [node name] [address] --asset [id|alias|all] -> uint
 	status [node name] [tx string] -> [status]
-	maketx [wallet name] [destination address] [amount] -> txString
+	maketx [wallet name] [destination address] [amount] --asset [id|alias] -> txString
 	refresh [node name] [wallet name] -> "wallet refreshed: " + [wallet name]
+	asset create [node name] [name] [symbol] --type [fixed|variable|nft] -> asset ID
 	remove [wallet name] [tx string] -> "transaction removed: " + [tx string]
-	send [node name] [tx string] -> "sent tx: " [tx string]
+	send [node name] [tx string] [--wait] -> "sent tx: " [tx string]
+	transfer --from [chain] --to [chain] --amount [nAVAX] --wallet [wallet name] -> balances
 	newkey -> privateKey
 */
 
 func init() {
+	AVAXWalletGetBalanceCmd.Flags().StringVar(&balanceAsset, "asset", "AVAX", "Asset ID or alias to check, or \"all\" for every asset held.")
+	AVAXWalletMakeTxCmd.Flags().StringVar(&makeTxAsset, "asset", "AVAX", "Asset ID, saved symbol or AVAX to send.")
+	AVAXWalletCreateCmd.Flags().StringVar(&walletNetworkID, "network-id", constants.LocalName, "Name or numeric ID of the network the wallet transacts on.")
+
+	resetTransferOpts(AVAXWalletTransferCmd)
+	AVAXWalletTransferCmd.Flags().StringVar(&transferOpts.from, "from", transferOpts.from, "Chain to export the AVAX from: X, P or C.")
+	AVAXWalletTransferCmd.Flags().StringVar(&transferOpts.to, "to", transferOpts.to, "Chain to import the AVAX into: X, P or C.")
+	AVAXWalletTransferCmd.Flags().Uint64Var(&transferOpts.amount, "amount", transferOpts.amount, "Amount of nAVAX to transfer.")
+	AVAXWalletTransferCmd.Flags().StringVar(&transferOpts.wallet, "wallet", transferOpts.wallet, "Wallet whose keys hold the AVAX.")
+	AVAXWalletTransferCmd.Flags().StringVar(&transferOpts.node, "node", transferOpts.node, "Node to issue the transactions to, defaulting to the first running node.")
+	AVAXWalletTransferCmd.Flags().DurationVar(&transferOpts.timeout, "timeout", transferOpts.timeout, "How long to wait for each transaction to be accepted.")
+
+	resetWaitOpts(AVAXWalletSendCmd)
+	AVAXWalletSendCmd.Flags().BoolVar(&waitOpts.wait, "wait", waitOpts.wait, "Wait for the transaction to be decided and save its receipt.")
+	AVAXWalletSendCmd.Flags().DurationVar(&waitOpts.timeout, "timeout", waitOpts.timeout, "How long to wait for the transaction to be decided.")
+
+	AVAXWalletCmd.AddCommand(AVAXWalletCreateCmd)
+	AVAXWalletCmd.AddCommand(AVAXWalletAddKeyCmd)
+	AVAXWalletCmd.AddCommand(AVAXWalletRefreshCmd)
+	AVAXWalletCmd.AddCommand(AVAXWalletMakeTxCmd)
+	AVAXWalletCmd.AddCommand(AVAXWalletRemoveCmd)
+	AVAXWalletCmd.AddCommand(AVAXWalletTransferCmd)
 	AVAXWalletCmd.AddCommand(AVAXWalletNewKeyCmd)
 	AVAXWalletCmd.AddCommand(AVAXWalletGetBalanceCmd)
 	AVAXWalletCmd.AddCommand(AVAXWalletSendCmd)
@@ -1,70 +1,283 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/ava-labs/avash/cfg"
-	"github.com/ava-labs/avash/node"
-	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"github.com/ybbus/jsonrpc"
 )
 
+var callRPCOpts struct {
+	save     string
+	allNodes bool
+}
+
+// endpointAliases maps the shorthands accepted by callrpc to API endpoints
+var endpointAliases = map[string]string{
+	"x":        "ext/bc/X",
+	"p":        "ext/P",
+	"c":        "ext/bc/C/rpc",
+	"c/avax":   "ext/bc/C/avax",
+	"info":     "ext/info",
+	"health":   "ext/health",
+	"admin":    "ext/admin",
+	"auth":     "ext/auth",
+	"ipcs":     "ext/ipcs",
+	"keystore": "ext/keystore",
+}
+
 // CallRPCCmd issues an RPC to a node endpoint using JSONRPC protocol
 var CallRPCCmd = &cobra.Command{
-	Use:     "callrpc [node name] [endpoint] [method] [JSON params] [var scope] [var name]",
-	Short:   "Issues an RPC call to a node.",
-	Long:    `Issues an RPC call to a node endpoint for the specified method and params.
-	Response is saved to the local varstore.`,
-	Example: `callrpc n1 ext/bc/X avm.getBalance {"address":"X-KqpU28P2ipUxfTfwaT847wWxyXB4XuWad","assetID":"AVAX"} s v`,
-	Args: cobra.MinimumNArgs(6),
+	Use:   "callrpc [node name] [endpoint] [method] [JSON params] [var scope] [var name]",
+	Short: "Issues an RPC call to a node.",
+	Long: `Issues an RPC call to a node endpoint for the specified method and params.
+	The endpoint is a path such as ext/bc/X or one of the aliases X, P, C, C/avax,
+	info, health, admin, auth, ipcs and keystore. The params are a JSON object or
+	array, and default to an empty one. The response is saved to the variable
+	given by --save, or by the var scope and var name.
+
+	With --all-nodes, the node name is a comma separated list of names and glob
+	patterns, and the call is issued to every running node it matches. The
+	responses are compared and the fields they differ in are listed.`,
+	Example: `callrpc n1 X avm.getBalance {"address":"X-local18jma8ppw3nhx5r4ap8clazz0dps7rv5u00z96u","assetID":"AVAX"} --save s.v
+callrpc n1 C eth_getBalance ["0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC","latest"]
+callrpc n* P platform.getHeight --all-nodes`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 3 || len(args) == 5 || len(args) > 6 {
+			return fmt.Errorf("requires a node name, endpoint and method, then optionally params, var scope and var name")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		defer resetCallRPCOpts(cmd)
 		log := cfg.Config.Log
-		meta, err := pmgr.ProcManager.Metadata(args[0])
+		endpoint := resolveEndpoint(args[1])
+		params, err := parseParams(args[3:], endpoint)
 		if err != nil {
-			log.Error("process not found: %s", args[0])
-			return
-		}
-		var md node.Metadata
-		if err = json.Unmarshal([]byte(meta), &md); err != nil {
-			log.Error("unable to unmarshal metadata for process %s: %s", args[0], err.Error())
+			log.Error(err.Error())
 			return
 		}
-		base := "http"
-		if md.HTTPTLS {
-			base = "https"
-		}
-		jrpcloc := fmt.Sprintf("%s://%s:%s/%s", base, md.Serverhost, md.HTTPport, args[1])
-		log.Info(jrpcloc)
-		rpcClient := jsonrpc.NewClient(jrpcloc)
-		argMap := make(map[string]interface{})
-		if err = json.Unmarshal([]byte(args[3]), &argMap); err != nil {
-			log.Error("invalid JSON object: %s", args[3])
+		if callRPCOpts.allNodes {
+			names, err := selectNodes(args[0])
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			responses := callAllNodes(names, endpoint, args[2], params)
+			printResponseDiff(names, responses)
+			saved := make(map[string]json.RawMessage)
+			for i, name := range names {
+				if responses[i].err == nil {
+					saved[name] = responses[i].result
+				}
+			}
+			saveResult(callRPCOpts.save, saved)
 			return
 		}
-		response, err := rpcClient.Call(args[2], argMap)
+		jrpcloc, err := apiClient.URL(args[0], endpoint)
 		if err != nil {
-			log.Error("rpcClient returned error: %s", err.Error())
-			return
-		}
-		if response.Error != nil {
-			log.Error("rpcClient returned error: %d, %s", response.Error.Code, response.Error.Message)
+			log.Error("process not found: %s", args[0])
 			return
 		}
-		resBytes, err := json.Marshal(response.Result)
-		if err != nil {
-			log.Error("rpcClient returned invalid JSON object: %v", response.Result)
+		log.Info(jrpcloc)
+		var result json.RawMessage
+		if err := apiClient.Call(args[0], endpoint, args[2], params, &result); err != nil {
+			log.Error(err.Error())
 			return
 		}
-		resVal := string(resBytes)
+		resVal := compactJSON(result)
 		log.Info("Response: %s", resVal)
-		store, err := AvashVars.Get(args[4])
-		if err != nil {
-			log.Error("store not found: %s", args[4])
-			return
+		if len(args) == 6 {
+			recordVar(args[4], args[5], resVal)
+			log.Info("Response saved to %q.%q", args[4], args[5])
 		}
-		store.Set(args[5], resVal)
-		log.Info("Response saved to %q.%q", args[4], args[5])
+		saveResult(callRPCOpts.save, resVal)
 	},
 }
+
+// nodeResponse is the response of one node to a call
+type nodeResponse struct {
+	result json.RawMessage
+	err    error
+}
+
+// resolveEndpoint returns the endpoint `s` is an alias of, or else `s`
+func resolveEndpoint(s string) string {
+	if endpoint, ok := endpointAliases[strings.ToLower(s)]; ok {
+		return endpoint
+	}
+	return s
+}
+
+// parseParams parses the JSON params given in `args`, if any. A scalar is
+// passed as the only element of an array. Calls to the C-chain's eth API
+// default to an empty array and all others to an empty object.
+func parseParams(args []string, endpoint string) (interface{}, error) {
+	if len(args) == 0 || args[0] == "" {
+		if endpoint == endpointAliases["c"] {
+			return []interface{}{}, nil
+		}
+		return map[string]interface{}{}, nil
+	}
+	params, err := decodeJSON([]byte(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON params: %s", args[0])
+	}
+	switch params.(type) {
+	case map[string]interface{}, []interface{}:
+		return params, nil
+	default:
+		return []interface{}{params}, nil
+	}
+}
+
+// callAllNodes issues the same call to each of the named nodes concurrently,
+// returning their responses in the same order
+func callAllNodes(names []string, endpoint string, method string, params interface{}) []nodeResponse {
+	responses := make([]nodeResponse, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			r := &responses[i]
+			r.err = apiClient.Call(name, endpoint, method, params, &r.result)
+		}(i, name)
+	}
+	wg.Wait()
+	return responses
+}
+
+// printResponseDiff prints the distinct responses of the named nodes and the
+// fields they differ in
+func printResponseDiff(names []string, responses []nodeResponse) {
+	log := cfg.Config.Log
+	var distinct []string
+	groups := make(map[string][]string)
+	fields := make([]map[string]string, len(names))
+	for i, name := range names {
+		r := responses[i]
+		value := ""
+		fields[i] = make(map[string]string)
+		if r.err != nil {
+			value = "error: " + r.err.Error()
+			fields[i]["error"] = r.err.Error()
+		} else {
+			value = compactJSON(r.result)
+			v, _ := decodeJSON(r.result)
+			flattenJSON("", v, fields[i])
+		}
+		if _, ok := groups[value]; !ok {
+			distinct = append(distinct, value)
+		}
+		groups[value] = append(groups[value], name)
+	}
+	for _, value := range distinct {
+		log.Info("Response from %s: %s", strings.Join(groups[value], ", "), value)
+	}
+	if len(distinct) > 1 {
+		diffs := diffFields(fields)
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader(append([]string{"Field"}, names...))
+		table.SetBorder(false)
+		for _, field := range diffs {
+			row := []string{field}
+			for i := range names {
+				value, ok := fields[i][field]
+				if !ok {
+					value = "-"
+				}
+				row = append(row, value)
+			}
+			table.Append(row)
+		}
+		table.Render()
+	}
+	log.Info("Consistent: %t", len(distinct) == 1)
+}
+
+// diffFields returns the sorted fields whose values aren't the same in all of
+// `fields`
+func diffFields(fields []map[string]string) []string {
+	all := make(map[string]bool)
+	for _, f := range fields {
+		for field := range f {
+			all[field] = true
+		}
+	}
+	var diffs []string
+	for field := range all {
+		value, ok := fields[0][field]
+		for _, f := range fields[1:] {
+			if v, present := f[field]; present != ok || v != value {
+				diffs = append(diffs, field)
+				break
+			}
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// flattenJSON adds the scalar fields of the decoded JSON value `v` to `fields`
+// by their path under `prefix`
+func flattenJSON(prefix string, v interface{}, fields map[string]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenJSON(key, elem, fields)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), elem, fields)
+		}
+	case nil:
+		fields[prefix] = "null"
+	default:
+		fields[prefix] = fmt.Sprint(v)
+	}
+}
+
+// decodeJSON decodes `b`, keeping numbers exact
+func decodeJSON(b []byte) (interface{}, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+// compactJSON returns `b` without insignificant whitespace
+func compactJSON(b []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
+
+func resetCallRPCOpts(cmd *cobra.Command) {
+	callRPCOpts.save = ""
+	callRPCOpts.allNodes = false
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	CallRPCCmd.Flags().StringVar(&callRPCOpts.save, "save", callRPCOpts.save, "Variable to save the response to, as scope.var.")
+	CallRPCCmd.Flags().BoolVar(&callRPCOpts.allNodes, "all-nodes", callRPCOpts.allNodes, "Call every running node matched by the node name and compare the responses.")
+}
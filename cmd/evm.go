@@ -0,0 +1,724 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/wallet"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// contractStore is the variable store recording the addresses of deployed
+// contracts by name
+const contractStore = "contracts"
+
+// weiDenomination is the number of decimal places of AVAX on the C-chain
+const weiDenomination = 18
+
+var evmOpts struct {
+	key      string
+	wallet   string
+	gas      uint64
+	gasPrice uint64
+	timeout  time.Duration
+	args     string
+	name     string
+	send     bool
+	filter   []string
+	save     string
+}
+
+// EVMCmd represents the evm command
+var EVMCmd = &cobra.Command{
+	Use:   "evm",
+	Short: "Tools for interacting with the C-chain.",
+	Long: `Tools for interacting with the C-chain. Using this command you can check
+	balances, transfer AVAX, deploy and call contracts and query logs through a
+	node's /ext/bc/C/rpc endpoint. Transactions are signed locally with the key
+	given by --key, or the first key of the wallet given by --wallet.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// EVMBalanceCmd checks the C-chain balance of an address
+var EVMBalanceCmd = &cobra.Command{
+	Use:   "balance [node name] [address]",
+	Short: "Checks the C-chain balance of an address.",
+	Long: `Checks the C-chain balance of an address, defaulting to the address of
+	--key or --wallet.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetEVMOpts(cmd)
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		var addr common.Address
+		if len(args) > 1 {
+			if !common.IsHexAddress(args[1]) {
+				log.Error("invalid address: %s", args[1])
+				return
+			}
+			addr = common.HexToAddress(args[1])
+		} else {
+			key, err := evmKey()
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			addr = ethcrypto.PubkeyToAddress(key.PublicKey)
+		}
+		client, err := evmClient(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		balance, err := client.BalanceAt(context.Background(), addr, nil)
+		if err != nil {
+			log.Error("rpcClient returned error: %s", err.Error())
+			return
+		}
+		log.Info("Balance: %s wei (%s AVAX)", balance, formatUnits(balance.String(), weiDenomination))
+	},
+}
+
+// EVMSendCmd transfers AVAX on the C-chain
+var EVMSendCmd = &cobra.Command{
+	Use:   "send [node name] [address] [amount]",
+	Short: "Sends AVAX to a C-chain address.",
+	Long: `Sends an amount of wei to a C-chain address from --key or --wallet,
+	waiting for the transaction to be mined.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetEVMOpts(cmd)
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		if !common.IsHexAddress(args[1]) {
+			log.Error("invalid address: %s", args[1])
+			return
+		}
+		to := common.HexToAddress(args[1])
+		amount, ok := new(big.Int).SetString(args[2], 10)
+		if !ok || amount.Sign() <= 0 {
+			log.Error("invalid amount: %s", args[2])
+			return
+		}
+		client, err := evmClient(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if _, err := evmTransact(client, &to, amount, nil); err != nil {
+			log.Error(err.Error())
+		}
+	},
+}
+
+// EVMDeployCmd deploys a contract to the C-chain
+var EVMDeployCmd = &cobra.Command{
+	Use:   "deploy [node name] [bytecode | abi file] [bin file]",
+	Short: "Deploys a contract to the C-chain.",
+	Long: `Deploys a contract to the C-chain. Takes either the contract bytecode, as hex
+	or a file holding it, or a file with the contract ABI followed by its
+	compiled bytecode. With an ABI, --args gives comma separated constructor
+	arguments. With --name the contract address is saved to "contracts".[name],
+	so the name can be given to call in place of the address.`,
+	Example: `evm deploy n1 Storage.abi Storage.bin --args 42 --name storage --wallet w`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetEVMOpts(cmd)
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		var (
+			code []byte
+			err  error
+		)
+		if len(args) < 3 {
+			if evmOpts.args != "" {
+				log.Error("constructor arguments need the contract ABI")
+				return
+			}
+			code, err = readHex(args[1])
+		} else {
+			code, err = constructorCode(args[1], args[2], evmOpts.args)
+		}
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		client, err := evmClient(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		receipt, err := evmTransact(client, nil, nil, code)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("ContractAddress: %s", receipt.ContractAddress.Hex())
+		if evmOpts.name != "" {
+			recordVar(contractStore, evmOpts.name, receipt.ContractAddress.Hex())
+			log.Info("Contract address saved to %q.%q", contractStore, evmOpts.name)
+		}
+	},
+}
+
+// EVMCallCmd calls a contract method
+var EVMCallCmd = &cobra.Command{
+	Use:   "call [node name] [address] [method signature] [args...]",
+	Short: "Calls a method of a C-chain contract.",
+	Long: `Calls a method of a C-chain contract. The method signature names the
+	method and its argument types, optionally followed by its return types, as in
+	"balanceOf(address)(uint256)". The call is read-only unless --send is given,
+	in which case it's signed and sent as a transaction. The address may be the
+	name of a contract saved by deploy.
+
+	--save saves the result to a variable given as scope.var: the single return
+	value, a JSON array of them if there are several, the raw result if there
+	are no return types, or the transaction hash with --send.`,
+	Example: `evm call n1 storage "store(uint256)" 7 --send --wallet w
+evm call n1 storage "retrieve()(uint256)" --save s.stored`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetEVMOpts(cmd)
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		to, err := resolveContract(args[1])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		method, inputs, outputs, err := parseMethodSignature(args[2])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		values, err := convertArgs(inputs, args[3:])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		packed, err := inputs.Pack(values...)
+		if err != nil {
+			log.Error("could not encode arguments: %s", err.Error())
+			return
+		}
+		data := append(methodSelector(method, inputs), packed...)
+		client, err := evmClient(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if evmOpts.send {
+			receipt, err := evmTransact(client, &to, nil, data)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			saveResult(evmOpts.save, receipt.TxHash.Hex())
+			return
+		}
+		msg := ethereum.CallMsg{To: &to, Data: data}
+		if key, err := evmKey(); err == nil {
+			msg.From = ethcrypto.PubkeyToAddress(key.PublicKey)
+		}
+		result, err := client.CallContract(context.Background(), msg, nil)
+		if err != nil {
+			log.Error("rpcClient returned error: %s", err.Error())
+			return
+		}
+		if len(outputs) == 0 {
+			log.Info("Result: 0x%s", hex.EncodeToString(result))
+			saveResult(evmOpts.save, "0x"+hex.EncodeToString(result))
+			return
+		}
+		decoded, err := outputs.Unpack(result)
+		if err != nil {
+			log.Error("could not decode result: %s", err.Error())
+			return
+		}
+		var results []string
+		for i, v := range decoded {
+			log.Info("Result[%d]: %v", i, v)
+			results = append(results, fmt.Sprint(v))
+		}
+		if len(results) == 1 {
+			saveResult(evmOpts.save, results[0])
+		} else {
+			saveResult(evmOpts.save, results)
+		}
+	},
+}
+
+// EVMLogsCmd queries C-chain logs
+var EVMLogsCmd = &cobra.Command{
+	Use:   "logs [node name] --filter [key=value,...]",
+	Short: "Lists C-chain logs matching a filter.",
+	Long: `Lists C-chain logs matching a filter. --filter takes comma separated
+	key=value pairs:
+
+	address  contract address or name, may be repeated
+	topic0   first topic, topic1 to topic3 for the others
+	from     first block, a number or "latest", defaulting to 0
+	to       last block, a number or "latest", defaulting to "latest"`,
+	Example: `evm logs n1 --filter address=storage,from=10`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetEVMOpts(cmd)
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		query, err := parseLogFilter(evmOpts.filter)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		client, err := evmClient(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		logs, err := client.FilterLogs(context.Background(), query)
+		if err != nil {
+			log.Error("rpcClient returned error: %s", err.Error())
+			return
+		}
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Block", "Tx Hash", "Index", "Address", "Topics", "Data"})
+		table.SetBorder(false)
+		for _, l := range logs {
+			var topics []string
+			for _, t := range l.Topics {
+				topics = append(topics, t.Hex())
+			}
+			table.Append([]string{
+				fmt.Sprint(l.BlockNumber),
+				l.TxHash.Hex(),
+				fmt.Sprint(l.Index),
+				l.Address.Hex(),
+				strings.Join(topics, "\n"),
+				"0x" + hex.EncodeToString(l.Data),
+			})
+		}
+		table.Render()
+	},
+}
+
+// evmClient returns a client of the C-chain API of the named node
+func evmClient(name string) (*ethclient.Client, error) {
+	url, err := apiClient.URL(name, "ext/bc/C/rpc")
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := apiClient.HTTP(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := rpc.DialHTTPWithClient(url, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
+}
+
+// evmKey returns the key given by --key, or else the first key of the wallet
+// given by --wallet
+func evmKey() (*ecdsa.PrivateKey, error) {
+	switch {
+	case evmOpts.key != "":
+		sk, err := wallet.ParsePrivateKey(evmOpts.key)
+		if err != nil {
+			return nil, err
+		}
+		return ethcrypto.ToECDSA(sk.Bytes())
+	case evmOpts.wallet != "":
+		w, err := lookupWallet(evmOpts.wallet)
+		if err != nil {
+			return nil, err
+		}
+		if len(w.Keys()) == 0 {
+			return nil, fmt.Errorf("wallet has no keys: %s", w.Name)
+		}
+		return ethcrypto.ToECDSA(w.Keys()[0].Bytes())
+	}
+	return nil, errors.New("no signing key, give --key or --wallet")
+}
+
+// evmTransact signs and sends a transaction to `to`, or a contract creation if
+// `to` is nil, then waits for it to be mined
+func evmTransact(client *ethclient.Client, to *common.Address, value *big.Int, data []byte) (*types.Receipt, error) {
+	log := cfg.Config.Log
+	key, err := evmKey()
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	ctx := context.Background()
+	from := ethcrypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	gasPrice := new(big.Int).SetUint64(evmOpts.gasPrice)
+	if evmOpts.gasPrice == 0 {
+		if gasPrice, err = client.SuggestGasPrice(ctx); err != nil {
+			return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+		}
+	}
+	gas := evmOpts.gas
+	if gas == 0 {
+		gas, err = client.EstimateGas(ctx, ethereum.CallMsg{
+			From:  from,
+			To:    to,
+			Value: value,
+			Data:  data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not estimate gas: %s", err.Error())
+		}
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       to,
+		Value:    value,
+		Data:     data,
+	}), types.NewEIP155Signer(chainID), key)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	log.Info("TxHash:%s", tx.Hash().Hex())
+	receipt, err := waitForReceipt(client, tx.Hash(), evmOpts.timeout, pollInterval)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("transaction %s reverted in block %s", tx.Hash().Hex(), receipt.BlockNumber)
+	}
+	log.Info("Mined in block %s, gas used: %d", receipt.BlockNumber, receipt.GasUsed)
+	return receipt, nil
+}
+
+// waitForReceipt polls every `interval` until the transaction `hash` is mined
+func waitForReceipt(client *ethclient.Client, hash common.Hash, timeout time.Duration, interval time.Duration) (*types.Receipt, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		receipt, err := client.TransactionReceipt(context.Background(), hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if err != ethereum.NotFound {
+			return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for transaction %s", hash.Hex())
+		}
+		time.Sleep(interval)
+	}
+}
+
+// resolveContract returns the address saved for the contract named `s`, or
+// else `s` parsed as an address
+func resolveContract(s string) (common.Address, error) {
+	if store, err := AvashVars.Get(contractStore); err == nil {
+		if v, err := store.Get(s); err == nil {
+			s = v
+		}
+	}
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address or unknown contract: %s", s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+// readHex decodes `s`, or the contents of the file named `s`, as hex
+func readHex(s string) ([]byte, error) {
+	if _, err := os.Stat(s); err == nil {
+		b, err := ioutil.ReadFile(s)
+		if err != nil {
+			return nil, err
+		}
+		s = string(b)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode: %s", err.Error())
+	}
+	return b, nil
+}
+
+// constructorCode returns the bytecode in `binFile` followed by the comma
+// separated `args` encoded for the constructor in `abiFile`
+func constructorCode(abiFile string, binFile string, args string) ([]byte, error) {
+	f, err := os.Open(abiFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	contract, err := abi.JSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABI %s: %s", abiFile, err.Error())
+	}
+	code, err := readHex(binFile)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	if args != "" {
+		values = strings.Split(args, ",")
+	}
+	converted, err := convertArgs(contract.Constructor.Inputs, values)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := contract.Pack("", converted...)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode constructor arguments: %s", err.Error())
+	}
+	return append(code, packed...), nil
+}
+
+// parseMethodSignature parses "name(types)" or "name(types)(return types)"
+func parseMethodSignature(sig string) (string, abi.Arguments, abi.Arguments, error) {
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, nil, fmt.Errorf("invalid method signature: %s", sig)
+	}
+	name, rest := sig[:open], sig[open+1:len(sig)-1]
+	in, out := rest, ""
+	if i := strings.Index(rest, ")("); i >= 0 {
+		in, out = rest[:i], rest[i+2:]
+	}
+	inputs, err := abiArguments(in)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	outputs, err := abiArguments(out)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return name, inputs, outputs, nil
+}
+
+// abiArguments parses comma separated ABI types
+func abiArguments(s string) (abi.Arguments, error) {
+	var args abi.Arguments
+	if strings.TrimSpace(s) == "" {
+		return args, nil
+	}
+	for _, t := range strings.Split(s, ",") {
+		typ, err := abi.NewType(strings.TrimSpace(t), "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABI type %s: %s", t, err.Error())
+		}
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return args, nil
+}
+
+// methodSelector returns the 4 byte selector of the method `name` taking
+// `inputs`
+func methodSelector(name string, inputs abi.Arguments) []byte {
+	var types []string
+	for _, arg := range inputs {
+		types = append(types, arg.Type.String())
+	}
+	return ethcrypto.Keccak256([]byte(name + "(" + strings.Join(types, ",") + ")"))[:4]
+}
+
+// convertArgs converts the string arguments `values` to the Go types the ABI
+// encoder expects for `args`
+func convertArgs(args abi.Arguments, values []string) ([]interface{}, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(args), len(values))
+	}
+	var converted []interface{}
+	for i, arg := range args {
+		v, err := convertArg(arg.Type, strings.TrimSpace(values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %d: %s", i, err.Error())
+		}
+		converted = append(converted, v)
+	}
+	return converted, nil
+}
+
+func convertArg(t abi.Type, s string) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an integer", s)
+		}
+		if err := checkIntRange(t, n); err != nil {
+			return nil, err
+		}
+		if t.Size > 64 {
+			return n, nil
+		}
+		v := reflect.New(t.GetType()).Elem()
+		if t.T == abi.IntTy {
+			v.SetInt(n.Int64())
+		} else {
+			v.SetUint(n.Uint64())
+		}
+		return v.Interface(), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(s)
+	case abi.StringTy:
+		return s, nil
+	case abi.AddressTy:
+		addr, err := resolveContract(s)
+		return addr, err
+	case abi.BytesTy:
+		return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	case abi.FixedBytesTy:
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > t.Size {
+			return nil, fmt.Errorf("%s is longer than %d bytes", s, t.Size)
+		}
+		v := reflect.New(t.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported argument type %s", t)
+}
+
+// checkIntRange returns an error if `n` doesn't fit the integer type `t`
+func checkIntRange(t abi.Type, n *big.Int) error {
+	var min, max *big.Int
+	if t.T == abi.IntTy {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+		min = new(big.Int).Neg(max)
+		max.Sub(max, big.NewInt(1))
+	} else {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(t.Size))
+		min = new(big.Int)
+		max.Sub(max, big.NewInt(1))
+	}
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 {
+		return fmt.Errorf("%s is out of range for %s", n, t)
+	}
+	return nil
+}
+
+// parseLogFilter builds a log query from key=value pairs
+func parseLogFilter(pairs []string) (ethereum.FilterQuery, error) {
+	query := ethereum.FilterQuery{FromBlock: new(big.Int)}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return query, fmt.Errorf("invalid filter %s, expected key=value", pair)
+		}
+		key, value := strings.ToLower(kv[0]), kv[1]
+		switch key {
+		case "address":
+			addr, err := resolveContract(value)
+			if err != nil {
+				return query, err
+			}
+			query.Addresses = append(query.Addresses, addr)
+		case "topic0", "topic1", "topic2", "topic3":
+			i := int(key[len(key)-1] - '0')
+			for len(query.Topics) <= i {
+				query.Topics = append(query.Topics, nil)
+			}
+			query.Topics[i] = append(query.Topics[i], common.HexToHash(value))
+		case "from", "to":
+			var block *big.Int
+			if value != "latest" {
+				n, ok := new(big.Int).SetString(value, 10)
+				if !ok {
+					return query, fmt.Errorf("invalid block number: %s", value)
+				}
+				block = n
+			}
+			if key == "from" {
+				query.FromBlock = block
+			} else {
+				query.ToBlock = block
+			}
+		default:
+			return query, fmt.Errorf("unknown filter key %s", key)
+		}
+	}
+	return query, nil
+}
+
+func resetEVMOpts(cmd *cobra.Command) {
+	evmOpts.key = ""
+	evmOpts.wallet = ""
+	evmOpts.gas = 0
+	evmOpts.gasPrice = 0
+	evmOpts.timeout = defaultTxTimeout
+	evmOpts.args = ""
+	evmOpts.name = ""
+	evmOpts.send = false
+	evmOpts.filter = nil
+	evmOpts.save = ""
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetEVMOpts(EVMCmd)
+	EVMCmd.PersistentFlags().StringVar(&evmOpts.key, "key", evmOpts.key, "Private key signing transactions.")
+	EVMCmd.PersistentFlags().StringVar(&evmOpts.wallet, "wallet", evmOpts.wallet, "Wallet whose first key signs transactions.")
+	EVMCmd.PersistentFlags().Uint64Var(&evmOpts.gas, "gas", evmOpts.gas, "Gas limit of transactions, estimated if unset.")
+	EVMCmd.PersistentFlags().Uint64Var(&evmOpts.gasPrice, "gas-price", evmOpts.gasPrice, "Gas price of transactions in wei, suggested by the node if unset.")
+	EVMCmd.PersistentFlags().DurationVar(&evmOpts.timeout, "timeout", evmOpts.timeout, "How long to wait for transactions to be mined.")
+	EVMDeployCmd.Flags().StringVar(&evmOpts.args, "args", evmOpts.args, "Comma separated constructor arguments.")
+	EVMDeployCmd.Flags().StringVar(&evmOpts.name, "name", evmOpts.name, "Name to save the contract address under.")
+	EVMCallCmd.Flags().BoolVar(&evmOpts.send, "send", evmOpts.send, "Send the call as a signed transaction.")
+	EVMCallCmd.Flags().StringVar(&evmOpts.save, "save", evmOpts.save, "Variable to save the result to, as scope.var.")
+	EVMLogsCmd.Flags().StringSliceVar(&evmOpts.filter, "filter", evmOpts.filter, "Comma separated key=value log filters.")
+
+	EVMCmd.AddCommand(EVMBalanceCmd)
+	EVMCmd.AddCommand(EVMSendCmd)
+	EVMCmd.AddCommand(EVMDeployCmd)
+	EVMCmd.AddCommand(EVMCallCmd)
+	EVMCmd.AddCommand(EVMLogsCmd)
+}
@@ -16,12 +16,18 @@ var ExitCmd = &cobra.Command{
 	Short: "Exit the shell.",
 	Long:  `Exit the shell, attempting to gracefully stop all processes first.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		pmgr.ProcManager.StopAllProcesses()
-		if pmgr.ProcManager.HasRunning() {
-			cfg.Config.Log.Fatal("Unable to stop all processes, exiting anyway...")
-			os.Exit(1)
-		}
-		cfg.Config.Log.Info("Cleanup successful, exiting...")
-		os.Exit(0)
+		exitShell(0)
 	},
 }
+
+// exitShell stops all processes and exits with `code`, or with 1 if they
+// couldn't all be stopped
+func exitShell(code int) {
+	pmgr.ProcManager.StopAllProcesses()
+	if pmgr.ProcManager.HasRunning() {
+		cfg.Config.Log.Fatal("Unable to stop all processes, exiting anyway...")
+		os.Exit(1)
+	}
+	cfg.Config.Log.Info("Cleanup successful, exiting...")
+	os.Exit(code)
+}
@@ -0,0 +1,71 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// HealthCmd checks the health of a node
+var HealthCmd = &cobra.Command{
+	Use:   "health [node name]",
+	Short: "Checks the health of a node.",
+	Long: `Checks the health of a node, defaulting to the first running node, and lists
+	the result of each of its health checks. The raw response is saved as JSON
+	to the variable given by --save.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		if len(args) > 0 {
+			apiOpts.node = args[0]
+		}
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var reply health.APIHealthClientReply
+		if err := apiClient.Call(name, "ext/health", "health.health", struct{}{}, &reply); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var checks []string
+		for check := range reply.Checks {
+			checks = append(checks, check)
+		}
+		sort.Strings(checks)
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Check", "Healthy", "Failures", "Duration", "Details"})
+		table.SetBorder(false)
+		for _, check := range checks {
+			result := reply.Checks[check]
+			details := result.Error.Message
+			if details == "" && result.Details != nil {
+				b, _ := json.Marshal(result.Details)
+				details = string(b)
+			}
+			table.Append([]string{
+				check,
+				fmt.Sprint(result.Error.Message == ""),
+				fmt.Sprint(result.ContiguousFailures),
+				result.Duration.String(),
+				details,
+			})
+		}
+		table.Render()
+		log.Info("Healthy: %t", reply.Healthy)
+		saveResult(apiOpts.save, reply)
+	},
+}
+
+func init() {
+	HealthCmd.Flags().StringVar(&apiOpts.save, "save", apiOpts.save, "Variable to save the response to, as scope.var.")
+}
@@ -0,0 +1,214 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var apiOpts struct {
+	node string
+	save string
+	grep string
+}
+
+// nodePeer is a peer of a node as reported by info.peers
+type nodePeer struct {
+	IP             string        `json:"ip"`
+	PublicIP       string        `json:"publicIP,omitempty"`
+	NodeID         string        `json:"nodeID"`
+	Version        string        `json:"version"`
+	LastSent       time.Time     `json:"lastSent"`
+	LastReceived   time.Time     `json:"lastReceived"`
+	Benched        []string      `json:"benched"`
+	ObservedUptime avajson.Uint8 `json:"observedUptime"`
+}
+
+// InfoCmd represents the info command
+var InfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Queries the info API of a node.",
+	Long: `Queries the info API of the node given by --node, defaulting to the first
+	running node. Results are printed as "Key: value" lines or tables, and the
+	raw response is saved as JSON to the variable given by --save.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// InfoNodeIDCmd prints the node ID of a node
+var InfoNodeIDCmd = &cobra.Command{
+	Use:   "node-id",
+	Short: "Prints the node ID of a node.",
+	Long:  `Prints the node ID of a node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		id, err := nodeID(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("NodeID: %s", id)
+		saveResult(apiOpts.save, id)
+	},
+}
+
+// InfoNetworkIDCmd prints the network ID of a node
+var InfoNetworkIDCmd = &cobra.Command{
+	Use:   "network-id",
+	Short: "Prints the network ID and name of a node.",
+	Long:  `Prints the network ID and name of a node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var network struct {
+			NetworkID   avajson.Uint32 `json:"networkID"`
+			NetworkName string         `json:"networkName"`
+		}
+		if err := apiClient.Call(name, "ext/info", "info.getNetworkID", struct{}{}, &network); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := apiClient.Call(name, "ext/info", "info.getNetworkName", struct{}{}, &network); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("NetworkID: %d", network.NetworkID)
+		log.Info("NetworkName: %s", network.NetworkName)
+		saveResult(apiOpts.save, network)
+	},
+}
+
+// InfoPeersCmd lists the peers of a node
+var InfoPeersCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "Lists the peers of a node.",
+	Long: `Lists the peers of a node, mapping the node IDs of managed processes back to
+	their names.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		peers, err := nodePeers(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		names := processNodeIDs()
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Node ID", "Process", "IP", "Version", "Uptime", "Last Received", "Benched"})
+		table.SetBorder(false)
+		for _, p := range peers {
+			table.Append([]string{
+				p.NodeID,
+				names[p.NodeID],
+				p.IP,
+				p.Version,
+				fmt.Sprintf("%d%%", p.ObservedUptime),
+				p.LastReceived.Format(time.RFC3339),
+				strings.Join(p.Benched, ", "),
+			})
+		}
+		table.Render()
+		log.Info("NumPeers: %d", len(peers))
+		saveResult(apiOpts.save, peers)
+	},
+}
+
+// InfoBootstrappedCmd checks whether a node has bootstrapped chains
+var InfoBootstrappedCmd = &cobra.Command{
+	Use:   "bootstrapped [chain]",
+	Short: "Checks whether a node has bootstrapped a chain.",
+	Long: `Checks whether a node has bootstrapped a chain, given by ID or alias, or
+	each of the X, P and C chains if none is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		chains := args
+		if len(chains) == 0 {
+			chains = []string{"X", "P", "C"}
+		}
+		bootstrapped := make(map[string]bool)
+		for _, chain := range chains {
+			done, err := isBootstrapped(name, chain)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			bootstrapped[chain] = done
+			log.Info("Bootstrapped %s: %t", chain, done)
+		}
+		saveResult(apiOpts.save, bootstrapped)
+	},
+}
+
+// nodePeers returns the peers of the named node
+func nodePeers(name string) ([]nodePeer, error) {
+	var reply struct {
+		Peers []nodePeer `json:"peers"`
+	}
+	if err := apiClient.Call(name, "ext/info", "info.peers", struct{}{}, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Peers, nil
+}
+
+// isBootstrapped returns whether the named node has bootstrapped `chain`
+func isBootstrapped(name string, chain string) (bool, error) {
+	var reply struct {
+		IsBootstrapped bool `json:"isBootstrapped"`
+	}
+	err := apiClient.Call(name, "ext/info", "info.isBootstrapped", struct {
+		Chain string `json:"chain"`
+	}{chain}, &reply)
+	return reply.IsBootstrapped, err
+}
+
+func resetAPIOpts(cmd *cobra.Command) {
+	apiOpts.node = ""
+	apiOpts.save = ""
+	apiOpts.grep = ""
+	resetChangedFlags(cmd)
+}
+
+// addAPIFlags adds the --node and --save flags shared by the node API commands
+func addAPIFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&apiOpts.node, "node", apiOpts.node, "Name of the node to query, defaulting to the first running node.")
+	cmd.PersistentFlags().StringVar(&apiOpts.save, "save", apiOpts.save, "Variable to save the response to, as scope.var.")
+}
+
+func init() {
+	addAPIFlags(InfoCmd)
+	InfoCmd.AddCommand(InfoNodeIDCmd)
+	InfoCmd.AddCommand(InfoNetworkIDCmd)
+	InfoCmd.AddCommand(InfoPeersCmd)
+	InfoCmd.AddCommand(InfoBootstrappedCmd)
+}
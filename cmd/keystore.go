@@ -0,0 +1,288 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var keystoreOpts struct {
+	token string
+	chain string
+}
+
+// KeystoreCmd represents the keystore command
+var KeystoreCmd = &cobra.Command{
+	Use:   "keystore",
+	Short: "Tools for managing the keystore users of nodes.",
+	Long: `Tools for managing the keystore users of nodes. Using this command you can
+	create, delete, list, export and import users and import private keys into
+	them. Requests go to the HTTP endpoint recorded for the node, and carry its
+	auth token if the node requires one. --token sets the token, which is then
+	remembered for later requests to the node.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if keystoreOpts.token != "" && len(args) > 0 {
+			tokenLock.Lock()
+			authTokens[args[0]] = keystoreOpts.token
+			tokenLock.Unlock()
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// KeystoreCreateUserCmd creates a keystore user
+var KeystoreCreateUserCmd = &cobra.Command{
+	Use:   "create-user [node name] [username] [password]",
+	Short: "Creates a keystore user on a node.",
+	Long:  `Creates a keystore user on a node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetKeystoreOpts(cmd)
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		user := api.UserPass{Username: args[1], Password: args[2]}
+		if err := apiClient.Call(args[0], "ext/keystore", "keystore.createUser", user, nil); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("user created: %s", user.Username)
+	},
+}
+
+// KeystoreDeleteUserCmd deletes a keystore user
+var KeystoreDeleteUserCmd = &cobra.Command{
+	Use:   "delete-user [node name] [username] [password]",
+	Short: "Deletes a keystore user from a node.",
+	Long:  `Deletes a keystore user from a node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetKeystoreOpts(cmd)
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		user := api.UserPass{Username: args[1], Password: args[2]}
+		if err := apiClient.Call(args[0], "ext/keystore", "keystore.deleteUser", user, nil); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("user deleted: %s", user.Username)
+	},
+}
+
+// KeystoreListUsersCmd lists the keystore users of a node
+var KeystoreListUsersCmd = &cobra.Command{
+	Use:   "list-users [node name]",
+	Short: "Lists the keystore users of a node.",
+	Long:  `Lists the keystore users of a node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetKeystoreOpts(cmd)
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		var reply struct {
+			Users []string `json:"users"`
+		}
+		if err := apiClient.Call(args[0], "ext/keystore", "keystore.listUsers", struct{}{}, &reply); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Username"})
+		table.SetBorder(false)
+		for _, user := range reply.Users {
+			table.Append([]string{user})
+		}
+		table.Render()
+	},
+}
+
+// KeystoreExportCmd exports a keystore user
+var KeystoreExportCmd = &cobra.Command{
+	Use:   "export [node name] [username] [password]",
+	Short: "Exports a keystore user from a node.",
+	Long: `Exports a keystore user from a node, printing the encrypted user to give to
+	import.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetKeystoreOpts(cmd)
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		var reply struct {
+			User     string              `json:"user"`
+			Encoding formatting.Encoding `json:"encoding"`
+		}
+		err := apiClient.Call(args[0], "ext/keystore", "keystore.exportUser", struct {
+			api.UserPass
+			Encoding formatting.Encoding `json:"encoding"`
+		}{api.UserPass{Username: args[1], Password: args[2]}, defaultEncoding}, &reply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("User: %s", reply.User)
+	},
+}
+
+// KeystoreImportCmd imports a keystore user
+var KeystoreImportCmd = &cobra.Command{
+	Use:   "import [node name] [username] [password] [user]",
+	Short: "Imports a keystore user into a node.",
+	Long: `Imports a keystore user, as printed by export, into a node. The password
+	must be the one the user was exported with.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetKeystoreOpts(cmd)
+		if len(args) < 4 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		err := apiClient.Call(args[0], "ext/keystore", "keystore.importUser", struct {
+			api.UserPass
+			User     string              `json:"user"`
+			Encoding formatting.Encoding `json:"encoding"`
+		}{api.UserPass{Username: args[1], Password: args[2]}, args[3], defaultEncoding}, nil)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("user imported: %s", args[1])
+	},
+}
+
+// KeystoreImportKeyCmd imports a private key into a keystore user
+var KeystoreImportKeyCmd = &cobra.Command{
+	Use:   "import-key [node name] [username] [password] [private key] --chain [X|P|C]",
+	Short: "Imports a private key into a keystore user.",
+	Long: `Imports a private key into a keystore user for the chain given by --chain,
+	printing the address it controls there.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetKeystoreOpts(cmd)
+		if len(args) < 4 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		chain, err := lookupChain(keystoreOpts.chain)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var reply api.JSONAddress
+		err = apiClient.Call(args[0], chain.endpoint, chain.prefix+".importKey", struct {
+			api.UserPass
+			PrivateKey string `json:"privateKey"`
+		}{api.UserPass{Username: args[1], Password: args[2]}, args[3]}, &reply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Address: %s", reply.Address)
+	},
+}
+
+// avashUser is the keystore user avash imports local private keys into, so
+// that nodes can sign transactions which spend from them. Its name and
+// password are random for each session, so nobody else knows its password.
+var avashUser = newSessionUser()
+
+// newSessionUser returns a keystore user with a random name and password
+func newSessionUser() api.UserPass {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("unable to generate keystore password: %s", err.Error()))
+	}
+	return api.UserPass{
+		Username: "avash-" + hex.EncodeToString(b[:4]),
+		Password: base64.RawURLEncoding.EncodeToString(b[4:]),
+	}
+}
+
+// ensureKeystoreUser creates `user` in the keystore of the named node if it
+// doesn't exist yet
+func ensureKeystoreUser(name string, user api.UserPass) error {
+	err := apiClient.Call(name, "ext/keystore", "keystore.createUser", user, nil)
+	if err != nil && !strings.Contains(err.Error(), "user already exists") {
+		return err
+	}
+	return nil
+}
+
+// importKeys imports `privateKeys` into the avash keystore user on the named
+// node for the chain at `endpoint`, returning the resulting addresses
+func importKeys(name string, endpoint string, method string, privateKeys []string) ([]string, error) {
+	if err := ensureKeystoreUser(name, avashUser); err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, pk := range privateKeys {
+		var reply api.JSONAddress
+		err := apiClient.Call(name, endpoint, method, struct {
+			api.UserPass
+			PrivateKey string `json:"privateKey"`
+		}{avashUser, pk}, &reply)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, reply.Address)
+	}
+	return addrs, nil
+}
+
+// spender resolves `from` to the keystore user which pays for transactions
+// issued to the named node. The keys of a wallet, or a private key, are
+// imported into the avash keystore user for the P-chain, anything else is
+// taken as a keystore username.
+func spender(name string, from string, password string) (api.UserPass, []string, error) {
+	if w, err := lookupWallet(from); err == nil {
+		keys, err := walletKeys(w)
+		if err != nil {
+			return avashUser, nil, err
+		}
+		addrs, err := importKeys(name, "ext/P", "platform.importKey", keys)
+		return avashUser, addrs, err
+	}
+	if strings.HasPrefix(from, "PrivateKey-") {
+		addrs, err := importKeys(name, "ext/P", "platform.importKey", []string{from})
+		return avashUser, addrs, err
+	}
+	return api.UserPass{Username: from, Password: password}, nil, nil
+}
+
+func resetKeystoreOpts(cmd *cobra.Command) {
+	keystoreOpts.token = ""
+	keystoreOpts.chain = "X"
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetKeystoreOpts(KeystoreCmd)
+	KeystoreCmd.PersistentFlags().StringVar(&keystoreOpts.token, "token", keystoreOpts.token, "API auth token of the node, remembered for later requests.")
+	KeystoreImportKeyCmd.Flags().StringVar(&keystoreOpts.chain, "chain", keystoreOpts.chain, "Chain to import the key for: X, P or C.")
+
+	KeystoreCmd.AddCommand(KeystoreCreateUserCmd)
+	KeystoreCmd.AddCommand(KeystoreDeleteUserCmd)
+	KeystoreCmd.AddCommand(KeystoreListUsersCmd)
+	KeystoreCmd.AddCommand(KeystoreExportCmd)
+	KeystoreCmd.AddCommand(KeystoreImportCmd)
+	KeystoreCmd.AddCommand(KeystoreImportKeyCmd)
+}
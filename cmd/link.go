@@ -0,0 +1,207 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/netproxy"
+	"github.com/ava-labs/avash/node"
+	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// proxySourceIP is the loopback address proxies connect to nodes from. Nodes
+// only remember the advertised IP of a peer which connected from that same
+// IP, so connecting from another one keeps them from learning a route around
+// the proxies.
+const proxySourceIP = "127.0.0.2"
+
+// linkProxies runs the proxies between nodes started with --proxied
+var linkProxies = netproxy.NewManager("127.0.0.1", proxySourceIP)
+
+// LinkCmd represents the link command
+var LinkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Injects latency and loss into the connections between nodes.",
+	Long: `Injects latency and loss into the connections between nodes started with
+	--proxied, in both directions. A node started with --proxied connects to the
+	nodes in its --bootstrap-ips and to every other running --proxied node
+	through a TCP proxy avash runs for each pair of nodes, and doesn't gossip
+	peer IPs, so nodes only reach each other through the proxies. Since nodes
+	only learn of each other when they start, nodes started without --proxied
+	are only reached if they're in the --bootstrap-ips.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// LinkDelayCmd delays the connections between two nodes
+var LinkDelayCmd = &cobra.Command{
+	Use:     "delay [node name] [node name] [duration]",
+	Short:   "Delays the connections between two nodes.",
+	Long:    `Delays everything sent between two nodes by a duration. A duration of 0 removes the delay.`,
+	Example: `link delay n1 n2 200ms`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		delay, err := time.ParseDuration(args[2])
+		if err != nil || delay < 0 {
+			log.Error("invalid duration: %s", args[2])
+			return
+		}
+		if err := checkProxied(args[0], args[1]); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		rules := linkProxies.Rules(args[0], args[1])
+		rules.Delay = delay
+		linkProxies.SetRules(args[0], args[1], rules)
+		log.Info("Delaying %s <-> %s by %s", args[0], args[1], delay)
+	},
+}
+
+// LinkDropCmd drops part of the traffic between two nodes
+var LinkDropCmd = &cobra.Command{
+	Use:   "drop [node name] [node name] [percentage]",
+	Short: "Drops part of the traffic between two nodes.",
+	Long: fmt.Sprintf(`Drops a percentage of the chunks of data sent between two nodes. Since a
+	TCP stream can't lose data, a dropped chunk is delivered after %s as
+	TCP would retransmit it, holding back the data after it. A percentage of 0
+	stops dropping.`, netproxy.RetransmitDelay),
+	Example: `link drop n1 n3 10%`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 3 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		percentage, err := strconv.ParseFloat(strings.TrimSuffix(args[2], "%"), 64)
+		if err != nil || percentage < 0 || percentage > 100 {
+			log.Error("invalid percentage: %s", args[2])
+			return
+		}
+		if err := checkProxied(args[0], args[1]); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		rules := linkProxies.Rules(args[0], args[1])
+		rules.Drop = percentage / 100
+		linkProxies.SetRules(args[0], args[1], rules)
+		log.Info("Dropping %g%% of %s <-> %s", percentage, args[0], args[1])
+	},
+}
+
+// LinkResetCmd removes the faults injected between two nodes
+var LinkResetCmd = &cobra.Command{
+	Use:   "reset [node name] [node name]",
+	Short: "Removes the delay and loss between two nodes.",
+	Long:  `Removes the delay and loss injected between two nodes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		linkProxies.SetRules(args[0], args[1], netproxy.Rules{})
+		cfg.Config.Log.Info("Reset %s <-> %s", args[0], args[1])
+	},
+}
+
+// LinkListCmd lists the proxied links between nodes
+var LinkListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the proxied links between nodes.",
+	Long:  `Lists the proxied links between nodes with their faults and open connections.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Node", "Node", "Delay", "Drop", "Partitioned", "Connections"})
+		table.SetBorder(false)
+		for _, l := range linkProxies.Links() {
+			table.Append([]string{
+				l.A,
+				l.B,
+				l.Rules.Delay.String(),
+				fmt.Sprintf("%g%%", l.Rules.Drop*100),
+				fmt.Sprint(l.Blocked),
+				fmt.Sprint(l.Conns),
+			})
+		}
+		table.Render()
+	},
+}
+
+// proxiedFlags returns the flags to launch the named node started with
+// --proxied with, which reach the managed nodes in its bootstrap IPs and every
+// other running --proxied node through proxies and don't gossip peer IPs
+func proxiedFlags(name string, nodeFlags node.Flags) (node.Flags, error) {
+	addrs := processStakingAddrs(pmgr.ProcManager.Names())
+	ips := strings.Split(nodeFlags.BootstrapIPs, ",")
+	for _, other := range proxiedNodes() {
+		for ip, target := range addrs {
+			if target == other && indexOf(ips, ip) < 0 {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	var launchIPs []string
+	for _, ip := range ips {
+		ip = strings.TrimSpace(ip)
+		if target, ok := addrs[ip]; ok && target != name {
+			proxied, err := linkProxies.Proxy(name, target, ip)
+			if err != nil {
+				return nodeFlags, err
+			}
+			cfg.Config.Log.Info("%s reaches %s at %s through %s", name, target, ip, proxied)
+			ip = proxied
+		}
+		if ip != "" {
+			launchIPs = append(launchIPs, ip)
+		}
+	}
+	nodeFlags.BootstrapIPs = strings.Join(launchIPs, ",")
+	nodeFlags.NetworkPeerListSize = 0
+	nodeFlags.NetworkPeerListGossipSize = 0
+	return nodeFlags, nil
+}
+
+// proxiedNodes returns the running nodes started with --proxied
+func proxiedNodes() []string {
+	var names []string
+	for _, name := range runningNodes() {
+		if nodeFlags, err := pmgr.ProcManager.NodeFlags(name); err == nil && nodeFlags.Proxied {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// checkProxied returns an error unless both named nodes were started with
+// --proxied
+func checkProxied(names ...string) error {
+	for _, name := range names {
+		nodeFlags, err := pmgr.ProcManager.NodeFlags(name)
+		if err != nil {
+			return err
+		}
+		if !nodeFlags.Proxied {
+			return fmt.Errorf("%s wasn't started with --proxied", name)
+		}
+	}
+	return nil
+}
+
+func init() {
+	LinkCmd.AddCommand(LinkDelayCmd)
+	LinkCmd.AddCommand(LinkDropCmd)
+	LinkCmd.AddCommand(LinkResetCmd)
+	LinkCmd.AddCommand(LinkListCmd)
+}
@@ -0,0 +1,425 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/wallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// latencyPollInterval is how often load generators poll for decisions, which
+// bounds the resolution of the measured latencies
+const latencyPollInterval = 20 * time.Millisecond
+
+var loadgenOpts struct {
+	chain    string
+	wallet   string
+	keys     string
+	nodes    string
+	tps      float64
+	duration time.Duration
+	amount   uint64
+	timeout  time.Duration
+	save     string
+}
+
+// LoadgenCmd generates transaction load against running nodes
+var LoadgenCmd = &cobra.Command{
+	Use:   "loadgen --wallet [wallet name] --tps [rate] --duration [duration]",
+	Short: "Generates a transaction load on the X or C chain.",
+	Long: `Generates a transaction load on the X or C chain. Each pre-funded key of
+	--wallet, or given by --keys, repeatedly transfers --amount to itself through
+	one of the nodes given by --nodes, defaulting to all running nodes. Keys are
+	assigned to nodes round robin and transactions are issued at --tps in total
+	for --duration.
+
+	Afterwards the number of issued, accepted and rejected transactions, the
+	achieved rate, the percentiles of the latency from submitting each
+	transaction to its acceptance and the errors seen are printed and saved to
+	the variable store given by --save.`,
+	Example: `loadgen --chain X --wallet w --tps 20 --duration 1m --nodes n1,n2,n3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetLoadgenOpts(cmd)
+		log := cfg.Config.Log
+		if loadgenOpts.tps <= 0 || loadgenOpts.duration <= 0 {
+			cmd.Help()
+			return
+		}
+		interval := time.Duration(float64(time.Second) / loadgenOpts.tps)
+		if interval <= 0 {
+			log.Error("invalid rate %g: --tps must be at most %d", loadgenOpts.tps, time.Second)
+			return
+		}
+		keys, err := loadgenKeys()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		nodes := runningNodes()
+		if loadgenOpts.nodes != "" {
+			nodes = strings.Split(loadgenOpts.nodes, ",")
+		}
+		if len(nodes) == 0 {
+			log.Error("no running nodes to send transactions to")
+			return
+		}
+		var senders []loadSender
+		for i, sk := range keys {
+			var (
+				s   loadSender
+				err error
+			)
+			node := nodes[i%len(nodes)]
+			switch strings.ToUpper(loadgenOpts.chain) {
+			case "X":
+				s, err = newXSender(node, sk)
+			case "C":
+				s, err = newCSender(node, sk)
+			default:
+				err = fmt.Errorf("unsupported chain %s, expected X or C", loadgenOpts.chain)
+			}
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			senders = append(senders, s)
+		}
+		log.Info("generating %.2f TPS on the %s-chain for %s with %d keys across %d nodes",
+			loadgenOpts.tps, strings.ToUpper(loadgenOpts.chain), loadgenOpts.duration, len(senders), len(nodes))
+
+		stats := &loadStats{errors: make(map[string]int)}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		start := time.Now()
+		deadline := start.Add(loadgenOpts.duration)
+		var wg sync.WaitGroup
+		for i := 0; time.Now().Before(deadline); i++ {
+			<-ticker.C
+			s := senders[i%len(senders)]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				issued, wait, err := s.issue()
+				if err != nil {
+					stats.fail(err)
+					return
+				}
+				stats.issue()
+				accepted, err := wait()
+				switch {
+				case err != nil:
+					stats.fail(err)
+				case accepted:
+					stats.accept(time.Since(issued))
+				default:
+					stats.reject()
+				}
+			}()
+		}
+		wg.Wait()
+		report := stats.report(time.Since(start))
+		printLoadReport(report)
+		for _, kv := range report {
+			recordVar(loadgenOpts.save, kv[0], kv[1])
+		}
+		log.Info("Results saved to %q", loadgenOpts.save)
+	},
+}
+
+// loadSender issues transactions from one key through one node
+type loadSender interface {
+	// issue sends a transaction, returning when it was submitted and a
+	// function that waits for it to be decided and reports whether it was
+	// accepted
+	issue() (time.Time, func() (bool, error), error)
+}
+
+// xSender transfers AVAX on the X-chain from a key to itself
+type xSender struct {
+	lock   sync.Mutex
+	node   string
+	wallet *wallet.Wallet
+	to     ids.ShortID
+}
+
+func newXSender(node string, sk *crypto.PrivateKeySECP256K1R) (*xSender, error) {
+	var network struct {
+		NetworkID avajson.Uint32 `json:"networkID"`
+	}
+	if err := apiClient.Call(node, "ext/info", "info.getNetworkID", struct{}{}, &network); err != nil {
+		return nil, err
+	}
+	w, err := wallet.New(node, uint32(network.NetworkID))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.AddKey(sk); err != nil {
+		return nil, err
+	}
+	if err := refreshWallet(node, w); err != nil {
+		return nil, err
+	}
+	return &xSender{node: node, wallet: w, to: sk.PublicKey().Address()}, nil
+}
+
+func (s *xSender) issue() (time.Time, func() (bool, error), error) {
+	// Transactions spend the change of the previous one, so they're issued
+	// in order to the same node
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	tx, err := s.wallet.CreateTx(s.wallet.Chain.AVAXAssetID, loadgenOpts.amount, s.to)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	str, err := formatting.EncodeWithChecksum(defaultEncoding, tx.Bytes())
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	var reply api.JSONTxID
+	err = apiClient.Call(s.node, "ext/bc/X", "avm.issueTx", api.FormattedTx{
+		Tx:       str,
+		Encoding: defaultEncoding,
+	}, &reply)
+	if err != nil {
+		s.wallet.RemoveTx(tx)
+		return time.Time{}, nil, err
+	}
+	return time.Now(), func() (bool, error) {
+		status, err := pollTxStatus(s.node, "X", reply.TxID, loadgenOpts.timeout, latencyPollInterval)
+		return status == "Accepted", err
+	}, nil
+}
+
+// cSender transfers AVAX on the C-chain from a key to itself
+type cSender struct {
+	lock     sync.Mutex
+	client   *ethclient.Client
+	key      *ecdsa.PrivateKey
+	from     common.Address
+	nonce    uint64
+	gasPrice *big.Int
+	signer   types.Signer
+}
+
+func newCSender(node string, sk *crypto.PrivateKeySECP256K1R) (*cSender, error) {
+	client, err := evmClient(node)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ethcrypto.ToECDSA(sk.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	from := ethcrypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	return &cSender{
+		client:   client,
+		key:      key,
+		from:     from,
+		nonce:    nonce,
+		gasPrice: gasPrice,
+		signer:   types.NewEIP155Signer(chainID),
+	}, nil
+}
+
+func (s *cSender) issue() (time.Time, func() (bool, error), error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    s.nonce,
+		GasPrice: s.gasPrice,
+		Gas:      21000,
+		To:       &s.from,
+		Value:    new(big.Int).SetUint64(loadgenOpts.amount),
+	}), s.signer, s.key)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if err := s.client.SendTransaction(context.Background(), tx); err != nil {
+		return time.Time{}, nil, fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	s.nonce++
+	return time.Now(), func() (bool, error) {
+		receipt, err := waitForReceipt(s.client, tx.Hash(), loadgenOpts.timeout, latencyPollInterval)
+		if err != nil {
+			return false, err
+		}
+		return receipt.Status == types.ReceiptStatusSuccessful, nil
+	}, nil
+}
+
+// loadStats collects the outcomes of generated transactions
+type loadStats struct {
+	lock      sync.Mutex
+	issued    int
+	accepted  int
+	rejected  int
+	failed    int
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+func (s *loadStats) issue() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.issued++
+}
+
+func (s *loadStats) accept(latency time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.accepted++
+	s.latencies = append(s.latencies, latency)
+}
+
+func (s *loadStats) reject() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rejected++
+}
+
+func (s *loadStats) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failed++
+	s.errors[err.Error()]++
+}
+
+// report returns the collected statistics as ordered name, value pairs
+func (s *loadStats) report(elapsed time.Duration) [][2]string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	report := [][2]string{
+		{"issued", fmt.Sprint(s.issued)},
+		{"accepted", fmt.Sprint(s.accepted)},
+		{"rejected", fmt.Sprint(s.rejected)},
+		{"errors", fmt.Sprint(s.failed)},
+		{"elapsed", elapsed.Round(time.Millisecond).String()},
+		{"tps", fmt.Sprintf("%.2f", float64(s.accepted)/elapsed.Seconds())},
+	}
+	for _, p := range []int{50, 90, 99, 100} {
+		name := fmt.Sprintf("p%d", p)
+		if p == 100 {
+			name = "max"
+		}
+		report = append(report, [2]string{name, percentile(s.latencies, p).String()})
+	}
+	var msgs []string
+	for msg := range s.errors {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	for i, msg := range msgs {
+		report = append(report, [2]string{fmt.Sprintf("error%d", i+1), fmt.Sprintf("%d x %s", s.errors[msg], msg)})
+	}
+	return report
+}
+
+// percentile returns the nearest-rank `p`th percentile of the sorted `d`
+func percentile(d []time.Duration, p int) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	rank := (p*len(d) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return d[rank-1]
+}
+
+func printLoadReport(report [][2]string) {
+	table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+	table.SetHeader([]string{"Metric", "Value"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+	for _, kv := range report {
+		table.Append([]string{kv[0], kv[1]})
+	}
+	table.Render()
+}
+
+// loadgenKeys returns the keys given by --keys, or else the keys of --wallet
+func loadgenKeys() ([]*crypto.PrivateKeySECP256K1R, error) {
+	if loadgenOpts.keys != "" {
+		var keys []*crypto.PrivateKeySECP256K1R
+		for _, s := range strings.Split(loadgenOpts.keys, ",") {
+			sk, err := wallet.ParsePrivateKey(s)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, sk)
+		}
+		return keys, nil
+	}
+	if loadgenOpts.wallet == "" {
+		return nil, fmt.Errorf("no keys to send from, give --wallet or --keys")
+	}
+	w, err := lookupWallet(loadgenOpts.wallet)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Keys()) == 0 {
+		return nil, fmt.Errorf("wallet has no keys: %s", w.Name)
+	}
+	return w.Keys(), nil
+}
+
+func resetLoadgenOpts(cmd *cobra.Command) {
+	loadgenOpts.chain = "X"
+	loadgenOpts.wallet = ""
+	loadgenOpts.keys = ""
+	loadgenOpts.nodes = ""
+	loadgenOpts.tps = 10
+	loadgenOpts.duration = 30 * time.Second
+	loadgenOpts.amount = 1
+	loadgenOpts.timeout = defaultTxTimeout
+	loadgenOpts.save = "loadgen"
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetLoadgenOpts(LoadgenCmd)
+	LoadgenCmd.Flags().StringVar(&loadgenOpts.chain, "chain", loadgenOpts.chain, "Chain to transact on: X or C.")
+	LoadgenCmd.Flags().StringVar(&loadgenOpts.wallet, "wallet", loadgenOpts.wallet, "Wallet whose keys send the transactions.")
+	LoadgenCmd.Flags().StringVar(&loadgenOpts.keys, "keys", loadgenOpts.keys, "Comma separated private keys sending the transactions, instead of --wallet.")
+	LoadgenCmd.Flags().StringVar(&loadgenOpts.nodes, "nodes", loadgenOpts.nodes, "Comma separated nodes to send transactions to, defaulting to all running nodes.")
+	LoadgenCmd.Flags().Float64Var(&loadgenOpts.tps, "tps", loadgenOpts.tps, "Target number of transactions issued per second.")
+	LoadgenCmd.Flags().DurationVar(&loadgenOpts.duration, "duration", loadgenOpts.duration, "How long to issue transactions for.")
+	LoadgenCmd.Flags().Uint64Var(&loadgenOpts.amount, "amount", loadgenOpts.amount, "Amount transferred by each transaction, in nAVAX on the X-chain or wei on the C-chain.")
+	LoadgenCmd.Flags().DurationVar(&loadgenOpts.timeout, "timeout", loadgenOpts.timeout, "How long to wait for each transaction to be decided.")
+	LoadgenCmd.Flags().StringVar(&loadgenOpts.save, "save", loadgenOpts.save, "Variable store to save the results to.")
+}
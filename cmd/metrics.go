@@ -0,0 +1,81 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/spf13/cobra"
+)
+
+// MetricsCmd prints the metrics of a node
+var MetricsCmd = &cobra.Command{
+	Use:   "metrics [node name]",
+	Short: "Prints the metrics of a node.",
+	Long: `Prints the Prometheus metrics of a node, defaulting to the first running
+	node. Comment lines are skipped, and only the lines matching the regular
+	expression given by --grep are printed if it's set. The printed lines are
+	saved to the variable given by --save.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		if len(args) > 0 {
+			apiOpts.node = args[0]
+		}
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var filter *regexp.Regexp
+		if apiOpts.grep != "" {
+			if filter, err = regexp.Compile(apiOpts.grep); err != nil {
+				log.Error("invalid --grep: %s", err.Error())
+				return
+			}
+		}
+		lines, err := nodeMetrics(name, filter)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		for _, line := range lines {
+			fmt.Fprintln(AvalancheShell.rl.Stdout(), line)
+		}
+		saveResult(apiOpts.save, strings.Join(lines, "\n"))
+	},
+}
+
+// nodeMetrics returns the metric lines of the named node, dropping comments
+// and, if `filter` isn't nil, the lines it doesn't match
+func nodeMetrics(name string, filter *regexp.Regexp) ([]string, error) {
+	body, err := apiClient.Get(name, "ext/metrics")
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if filter != nil && !filter.MatchString(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func init() {
+	MetricsCmd.Flags().StringVar(&apiOpts.node, "node", apiOpts.node, "Name of the node to query, defaulting to the first running node.")
+	MetricsCmd.Flags().StringVar(&apiOpts.save, "save", apiOpts.save, "Variable to save the output to, as scope.var.")
+	MetricsCmd.Flags().StringVar(&apiOpts.grep, "grep", apiOpts.grep, "Regular expression the printed lines must match.")
+}
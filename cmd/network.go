@@ -1,16 +1,44 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/ava-labs/avash/cfg"
 	"github.com/ava-labs/avash/network"
+	"github.com/ava-labs/avash/node"
+	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/ava-labs/avash/utils/archive"
+	"github.com/kennygrant/sanitize"
 	"github.com/spf13/cobra"
 )
 
+// networkManifest is the name of the process definitions file in a network snapshot
+const networkManifest = "processes.json"
+
+// NetworkSnapshot is the set of process definitions stored in a network snapshot
+type NetworkSnapshot struct {
+	Group string
+	Nodes []SnapshotNode
+}
+
+// SnapshotNode is the definition of a single node in a network snapshot
+type SnapshotNode struct {
+	Name    string
+	DataDir string
+	Flags   node.Flags
+}
+
 // NetworkCommand represents the network command
 var NetworkCommand = &cobra.Command{
 	Use:   "network",
-	Short: "Tools for interacting with remote hosts.",
-	Long:  `Tools for interacting with remote hosts.`,
+	Short: "Tools for interacting with local and remote networks.",
+	Long:  `Tools for interacting with local and remote networks.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -58,7 +86,246 @@ var SSHRemoveCommand = &cobra.Command{
 	},
 }
 
+var forkGroup string
+
+// NetworkSnapshotCommand snapshots every node of a local group
+var NetworkSnapshotCommand = &cobra.Command{
+	Use:   "snapshot [group] [tag]",
+	Short: "Snapshots every node in a group of local nodes.",
+	Long: `Snapshots the data directories and process definitions of every node in the
+	group under the tag, as a single archive. Running nodes are stopped while their 
+	data is archived, then restarted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		group, tag := args[0], sanitize.BaseName(args[1])
+		snapshot := NetworkSnapshot{Group: group}
+		root := filepath.Dir(nodeDataPath(group))
+		var paths []string
+		for _, name := range pmgr.ProcManager.Names() {
+			nodeFlags, err := pmgr.ProcManager.NodeFlags(name)
+			if err != nil || nodeFlags.Group != group {
+				continue
+			}
+			if nodeFlags.DBType == "memdb" {
+				log.Warn("%s uses memdb, its database isn't persisted to disk", name)
+			}
+			nodePaths, err := nodeDataPaths(name)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			datadir := filepath.Base(nodeDataPath(name))
+			for _, p := range nodePaths {
+				paths = append(paths, filepath.Join(datadir, p))
+			}
+			snapshot.Nodes = append(snapshot.Nodes, SnapshotNode{
+				Name:    name,
+				DataDir: datadir,
+				Flags:   nodeFlags,
+			})
+		}
+		if len(snapshot.Nodes) == 0 {
+			log.Error("no nodes in group: %s", group)
+			return
+		}
+		manifest, err := json.MarshalIndent(snapshot, "", "    ")
+		if err != nil {
+			log.Error("unable to marshal process definitions: %s", err.Error())
+			return
+		}
+		dst := networkSnapshotPath(tag)
+		err = withNodesStopped(snapshot.Nodes, func() error {
+			return archive.CreateWithFiles(dst, root, paths, map[string][]byte{networkManifest: manifest})
+		})
+		if err != nil {
+			log.Error("unable to snapshot group %s: %s", group, err.Error())
+			return
+		}
+		log.Info("Snapshot of %d nodes in group %s saved to: %s", len(snapshot.Nodes), group, dst)
+	},
+}
+
+// NetworkForkCommand starts a copy of a network snapshot as a new group
+var NetworkForkCommand = &cobra.Command{
+	Use:   "fork [tag] --as [new group]",
+	Short: "Starts a copy of a network snapshot as a new group.",
+	Long: `Starts a copy of every node in the network snapshot under the tag as a new 
+	group. Each node is named "[new group]-[original name]" and gets re-allocated 
+	ports, and bootstrap IPs between the nodes are rewritten to match.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer func() {
+			forkGroup = ""
+		}()
+		if len(args) < 1 || forkGroup == "" {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		tag := sanitize.BaseName(args[0])
+		src := networkSnapshotPath(tag)
+		if _, err := os.Stat(src); err != nil {
+			log.Error("network snapshot not found: %s", src)
+			return
+		}
+		tmpdir, err := ioutil.TempDir(cfg.Config.DataDir, "fork-")
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		defer os.RemoveAll(tmpdir)
+		if err := archive.Extract(src, tmpdir); err != nil {
+			log.Error("unable to extract network snapshot: %s", err.Error())
+			return
+		}
+		manifest, err := ioutil.ReadFile(filepath.Join(tmpdir, networkManifest))
+		if err != nil {
+			log.Error("invalid network snapshot: %s", err.Error())
+			return
+		}
+		var snapshot NetworkSnapshot
+		if err := json.Unmarshal(manifest, &snapshot); err != nil {
+			log.Error("invalid network snapshot: %s", err.Error())
+			return
+		}
+		nodes, err := forkNodes(snapshot, forkGroup)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		for _, n := range nodes {
+			if datapath := nodeDataPath(n.Name); fileExists(datapath) {
+				log.Error("data directory already exists for %s: %s", n.Name, datapath)
+				return
+			}
+		}
+		var created []string
+		for i, n := range nodes {
+			if err := forkNode(n, filepath.Join(tmpdir, snapshot.Nodes[i].DataDir)); err != nil {
+				log.Error("unable to fork %s: %s", n.Name, err.Error())
+				removeForkedNodes(append(created, n.Name))
+				return
+			}
+			created = append(created, n.Name)
+			log.Info("Created process %s.", n.Name)
+		}
+		log.Info("Forked %d nodes from %s as group %s", len(nodes), tag, forkGroup)
+	},
+}
+
+// forkNode moves the data directory `extracted` of the forked node `n` into
+// place, if the snapshot holds one, then creates and starts its process
+func forkNode(n SnapshotNode, extracted string) error {
+	if fileExists(extracted) {
+		if err := os.Rename(extracted, nodeDataPath(n.Name)); err != nil {
+			return err
+		}
+	}
+	if err := addNodeProcess(n.Name, n.Flags); err != nil {
+		return err
+	}
+	return pmgr.ProcManager.StartProcess(n.Name)
+}
+
+// removeForkedNodes removes the processes and data directories of the named
+// forked nodes, undoing a fork which failed partway
+func removeForkedNodes(names []string) {
+	log := cfg.Config.Log
+	for _, name := range names {
+		if _, err := pmgr.ProcManager.Metadata(name); err == nil {
+			if running, _ := pmgr.ProcManager.IsRunning(name); running {
+				pmgr.ProcManager.StopProcessAndWait(name, stopTimeout)
+			}
+			if err := pmgr.ProcManager.RemoveProcess(name); err != nil {
+				log.Error(err.Error())
+			}
+			linkProxies.Remove(name)
+		}
+		if err := os.RemoveAll(nodeDataPath(name)); err != nil {
+			log.Error(err.Error())
+		}
+	}
+}
+
+// fileExists returns whether `fp` exists
+func fileExists(fp string) bool {
+	_, err := os.Stat(fp)
+	return err == nil
+}
+
+// networkSnapshotPath returns the location of the network snapshot under `tag`
+func networkSnapshotPath(tag string) string {
+	return filepath.Join(cfg.Config.DataDir, "snapshots", "networks", tag+".tar.gz")
+}
+
+// forkNodes returns the nodes of `snapshot` renamed into `group`, with
+// re-allocated ports and bootstrap IPs rewritten to point within the group
+func forkNodes(snapshot NetworkSnapshot, group string) ([]SnapshotNode, error) {
+	taken := takenPorts()
+	stakingAddrs := make(map[string]string)
+	var nodes []SnapshotNode
+	for _, n := range snapshot.Nodes {
+		name := group + "-" + n.Name
+		if _, err := pmgr.ProcManager.Metadata(name); err == nil {
+			return nil, fmt.Errorf("Process with name %s already exists", name)
+		}
+		forked := n.Flags
+		forked.Group = group
+		forked.Meta = ""
+		node.AllocatePorts(&forked, taken)
+		oldAddr := n.Flags.PublicIP + ":" + strconv.FormatUint(uint64(n.Flags.StakingPort), 10)
+		stakingAddrs[oldAddr] = forked.PublicIP + ":" + strconv.FormatUint(uint64(forked.StakingPort), 10)
+		nodes = append(nodes, SnapshotNode{
+			Name:    name,
+			DataDir: filepath.Base(nodeDataPath(name)),
+			Flags:   forked,
+		})
+	}
+	for i := range nodes {
+		var ips []string
+		for _, ip := range strings.Split(nodes[i].Flags.BootstrapIPs, ",") {
+			if addr, ok := stakingAddrs[ip]; ok {
+				ip = addr
+			}
+			ips = append(ips, ip)
+		}
+		nodes[i].Flags.BootstrapIPs = strings.Join(ips, ",")
+	}
+	return nodes, nil
+}
+
+// withNodesStopped runs `f` while all of `nodes` are stopped, restarting the
+// nodes which were running afterwards
+func withNodesStopped(nodes []SnapshotNode, f func() error) error {
+	var stopped []string
+	defer func() {
+		for _, name := range stopped {
+			pmgr.ProcManager.StartProcess(name)
+		}
+	}()
+	for _, n := range nodes {
+		running, err := pmgr.ProcManager.IsRunning(n.Name)
+		if err != nil {
+			return err
+		}
+		if !running {
+			continue
+		}
+		if err := pmgr.ProcManager.StopProcessAndWait(n.Name, stopTimeout); err != nil {
+			return err
+		}
+		stopped = append(stopped, n.Name)
+	}
+	return f()
+}
+
 func init() {
 	NetworkCommand.AddCommand(SSHDeployCommand)
+	NetworkCommand.AddCommand(NetworkForkCommand)
 	NetworkCommand.AddCommand(SSHRemoveCommand)
+	NetworkCommand.AddCommand(NetworkSnapshotCommand)
+	NetworkForkCommand.Flags().StringVar(&forkGroup, "as", forkGroup, "Name of the group to start the forked nodes in.")
 }
\ No newline at end of file
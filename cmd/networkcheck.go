@@ -0,0 +1,386 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var checkOpts struct {
+	selector      string
+	maxLag        uint64
+	save          string
+	exitOnFailure bool
+}
+
+// nodeState is what network check compares across nodes
+type nodeState struct {
+	Name string `json:"name"`
+	// PHeight and PLastAccepted are the height and ID of the last accepted
+	// P-chain block. The ID is only known if the node's index is enabled.
+	PHeight       uint64 `json:"pHeight"`
+	PLastAccepted string `json:"pLastAccepted,omitempty"`
+	// XAccepted is the number of accepted X-chain transactions, which is only
+	// known if the node's index is enabled
+	XAccepted *uint64 `json:"xAccepted,omitempty"`
+	// CHeight is the height of the last accepted C-chain block and CHash the
+	// hash of its block at the lowest height reported by the checked nodes
+	CHeight       uint64 `json:"cHeight"`
+	CHash         string `json:"cHash"`
+	Validators    string `json:"validators"`
+	NumValidators int    `json:"numValidators"`
+	Peers         int    `json:"peers"`
+	// Missing holds the other checked nodes it isn't connected to
+	Missing []string `json:"missing,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+
+	peerIDs      []string
+	pHeightKnown bool
+	cHeightKnown bool
+	peersKnown   bool
+}
+
+// NetworkCheckCommand checks that running nodes agree on the state of the network
+var NetworkCheckCommand = &cobra.Command{
+	Use:   "check",
+	Short: "Checks that running nodes agree on the state of the network.",
+	Long: `Checks that the running nodes matched by --selector, a comma separated list
+	of names and glob patterns defaulting to all of them, agree on the state of
+	the network. It compares the last accepted P-chain blocks, X-chain
+	transactions and C-chain blocks, the current validator set and the peers of
+	each node, and reports nodes which diverged, lag more than --max-lag behind
+	the others or aren't connected to every other checked node.
+	Last accepted P-chain block IDs and X-chain transactions are only compared
+	for nodes started with --index-enabled.
+
+	Ends with "Consistent: true" or "Consistent: false", so scripts can use it
+	as a gate. With --exit-on-failure, the shell stops every process and exits
+	with status 1 if the check fails.`,
+	Example: `network check --selector "n*" --max-lag 2`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetCheckOpts(cmd)
+		log := cfg.Config.Log
+		names, err := selectNodes(checkOpts.selector)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		states := checkNodes(names)
+		printNodeStates(states)
+		issues := compareNodeStates(states, checkOpts.maxLag)
+		for _, issue := range issues {
+			log.Warn(issue)
+		}
+		saveResult(checkOpts.save, states)
+		log.Info("Consistent: %t", len(issues) == 0)
+		if len(issues) > 0 && checkOpts.exitOnFailure {
+			exitShell(1)
+		}
+	},
+}
+
+// checkNodes collects the state of each of the named nodes concurrently
+func checkNodes(names []string) []*nodeState {
+	states := make([]*nodeState, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			states[i] = checkNode(name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	ids := processNodeIDs()
+	for _, s := range states {
+		if !s.peersKnown {
+			continue
+		}
+		connected := make(map[string]bool)
+		for _, id := range s.peerIDs {
+			connected[ids[id]] = true
+		}
+		for _, other := range names {
+			if other != s.Name && !connected[other] {
+				s.Missing = append(s.Missing, other)
+			}
+		}
+	}
+
+	// Compare C-chain blocks at a height every node which reported its
+	// height reached
+	var (
+		common uint64
+		known  bool
+	)
+	for _, s := range states {
+		if s.cHeightKnown && (!known || s.CHeight < common) {
+			common = s.CHeight
+			known = true
+		}
+	}
+	for _, s := range states {
+		if !s.cHeightKnown {
+			continue
+		}
+		wg.Add(1)
+		go func(s *nodeState) {
+			defer wg.Done()
+			hash, err := evmBlockHash(s.Name, common)
+			if err != nil {
+				s.Errors = append(s.Errors, fmt.Sprintf("C-chain block %d: %s", common, err.Error()))
+				return
+			}
+			s.CHash = hash
+		}(s)
+	}
+	wg.Wait()
+	return states
+}
+
+// checkNode collects the state of the named node, recording the queries that
+// failed in its errors
+func checkNode(name string) *nodeState {
+	s := &nodeState{Name: name}
+	fail := func(what string, err error) {
+		s.Errors = append(s.Errors, fmt.Sprintf("%s: %s", what, err.Error()))
+	}
+	var err error
+	if s.PHeight, err = platformHeight(name); err != nil {
+		fail("P-chain height", err)
+	} else {
+		s.pHeightKnown = true
+	}
+	if id, _, err := indexLastAccepted(name, "ext/index/P/block"); err == nil {
+		s.PLastAccepted = id
+	}
+	if _, index, err := indexLastAccepted(name, "ext/index/X/tx"); err == nil {
+		accepted := index + 1
+		s.XAccepted = &accepted
+	}
+	if s.CHeight, err = evmBlockNumber(name); err != nil {
+		fail("C-chain height", err)
+	} else {
+		s.cHeightKnown = true
+	}
+	if s.Validators, s.NumValidators, err = validatorSetDigest(name); err != nil {
+		fail("validators", err)
+	}
+	peers, err := nodePeers(name)
+	if err != nil {
+		fail("peers", err)
+	} else {
+		s.peersKnown = true
+	}
+	s.Peers = len(peers)
+	for _, p := range peers {
+		s.peerIDs = append(s.peerIDs, p.NodeID)
+	}
+	return s
+}
+
+// compareNodeStates returns the ways in which `states` disagree
+func compareNodeStates(states []*nodeState, maxLag uint64) []string {
+	var issues []string
+	for _, s := range states {
+		for _, err := range s.Errors {
+			issues = append(issues, fmt.Sprintf("%s failed to report %s", s.Name, err))
+		}
+	}
+
+	heights := map[string]func(s *nodeState) (uint64, bool){
+		"P-chain": func(s *nodeState) (uint64, bool) { return s.PHeight, s.pHeightKnown },
+		"X-chain": func(s *nodeState) (uint64, bool) {
+			if s.XAccepted == nil {
+				return 0, false
+			}
+			return *s.XAccepted, true
+		},
+		"C-chain": func(s *nodeState) (uint64, bool) { return s.CHeight, s.cHeightKnown },
+	}
+	units := map[string]string{"P-chain": "blocks", "X-chain": "transactions", "C-chain": "blocks"}
+	for _, chain := range []string{"P-chain", "X-chain", "C-chain"} {
+		var top uint64
+		for _, s := range states {
+			if h, ok := heights[chain](s); ok && h > top {
+				top = h
+			}
+		}
+		for _, s := range states {
+			if h, ok := heights[chain](s); ok && top-h > maxLag {
+				issues = append(issues, fmt.Sprintf("%s is %d %s %s behind", s.Name, top-h, chain, units[chain]))
+			}
+		}
+	}
+
+	pBlocks := make(map[string]map[string][]string)
+	for _, s := range states {
+		if s.PLastAccepted == "" {
+			continue
+		}
+		height := fmt.Sprint(s.PHeight)
+		if pBlocks[height] == nil {
+			pBlocks[height] = make(map[string][]string)
+		}
+		pBlocks[height][s.PLastAccepted] = append(pBlocks[height][s.PLastAccepted], s.Name)
+	}
+	for height, blocks := range pBlocks {
+		if len(blocks) > 1 {
+			issues = append(issues, fmt.Sprintf("P-chain diverged at height %s: %s", height, describeGroups(blocks)))
+		}
+	}
+
+	hashes := make(map[string][]string)
+	validators := make(map[string][]string)
+	for _, s := range states {
+		if s.CHash != "" {
+			hashes[s.CHash] = append(hashes[s.CHash], s.Name)
+		}
+		if s.Validators != "" {
+			validators[s.Validators] = append(validators[s.Validators], s.Name)
+		}
+	}
+	if len(hashes) > 1 {
+		issues = append(issues, fmt.Sprintf("C-chain diverged: %s", describeGroups(hashes)))
+	}
+	if len(validators) > 1 {
+		issues = append(issues, fmt.Sprintf("validator sets differ: %s", describeGroups(validators)))
+	}
+
+	for _, s := range states {
+		if len(s.Missing) > 0 {
+			issues = append(issues, fmt.Sprintf("%s isn't connected to %s", s.Name, strings.Join(s.Missing, ", ")))
+		}
+	}
+	return issues
+}
+
+// describeGroups describes the names grouped by the value they reported
+func describeGroups(groups map[string][]string) string {
+	var parts []string
+	for value, names := range groups {
+		parts = append(parts, fmt.Sprintf("%s has %s", strings.Join(names, ", "), value))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+// printNodeStates prints a table of `states`
+func printNodeStates(states []*nodeState) {
+	table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+	table.SetHeader([]string{"Node", "P Height", "P Last Accepted", "X Accepted", "C Height", "C Hash", "Validators", "Peers"})
+	table.SetBorder(false)
+	for _, s := range states {
+		xAccepted := "-"
+		if s.XAccepted != nil {
+			xAccepted = fmt.Sprint(*s.XAccepted)
+		}
+		peers := fmt.Sprint(s.Peers)
+		if len(s.Missing) > 0 {
+			peers += fmt.Sprintf(" (missing %s)", strings.Join(s.Missing, ", "))
+		}
+		table.Append([]string{
+			s.Name,
+			fmt.Sprint(s.PHeight),
+			s.PLastAccepted,
+			xAccepted,
+			fmt.Sprint(s.CHeight),
+			s.CHash,
+			fmt.Sprintf("%d (%s)", s.NumValidators, s.Validators),
+			peers,
+		})
+	}
+	table.Render()
+}
+
+// indexLastAccepted returns the ID and index of the last accepted container
+// of the index API `endpoint` on the named node
+func indexLastAccepted(name string, endpoint string) (string, uint64, error) {
+	var reply struct {
+		ID    string         `json:"id"`
+		Index avajson.Uint64 `json:"index"`
+	}
+	err := apiClient.Call(name, endpoint, "index.getLastAccepted", struct {
+		Encoding string `json:"encoding"`
+	}{"hex"}, &reply)
+	return reply.ID, uint64(reply.Index), err
+}
+
+// evmBlockNumber returns the height of the last accepted C-chain block of the
+// named node
+func evmBlockNumber(name string) (uint64, error) {
+	var reply string
+	if err := apiClient.Call(name, "ext/bc/C/rpc", "eth_blockNumber", []interface{}{}, &reply); err != nil {
+		return 0, err
+	}
+	return hexutil.DecodeUint64(reply)
+}
+
+// evmBlockHash returns the hash of the C-chain block at `height` on the named
+// node
+func evmBlockHash(name string, height uint64) (string, error) {
+	var reply struct {
+		Hash string `json:"hash"`
+	}
+	err := apiClient.Call(name, "ext/bc/C/rpc", "eth_getBlockByNumber", []interface{}{hexutil.EncodeUint64(height), false}, &reply)
+	if err != nil {
+		return "", err
+	}
+	if reply.Hash == "" {
+		return "", fmt.Errorf("no block at height %d", height)
+	}
+	return reply.Hash, nil
+}
+
+// validatorSetDigest returns a short digest of the current primary network
+// validators of the named node, and their number
+func validatorSetDigest(name string) (string, int, error) {
+	var reply struct {
+		Validators []platformValidator `json:"validators"`
+	}
+	if err := apiClient.Call(name, "ext/P", "platform.getCurrentValidators", struct{}{}, &reply); err != nil {
+		return "", 0, err
+	}
+	var entries []string
+	for _, v := range reply.Validators {
+		var weight uint64
+		if v.Weight != nil {
+			weight = uint64(*v.Weight)
+		} else if v.StakeAmount != nil {
+			weight = uint64(*v.StakeAmount)
+		}
+		entries = append(entries, fmt.Sprintf("%s:%d:%d:%d", v.NodeID, weight, v.StartTime, v.EndTime))
+	}
+	sort.Strings(entries)
+	digest := sha256.Sum256([]byte(strings.Join(entries, ",")))
+	return hex.EncodeToString(digest[:4]), len(entries), nil
+}
+
+func resetCheckOpts(cmd *cobra.Command) {
+	checkOpts.selector = ""
+	checkOpts.maxLag = 0
+	checkOpts.save = ""
+	checkOpts.exitOnFailure = false
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	NetworkCommand.AddCommand(NetworkCheckCommand)
+	NetworkCheckCommand.Flags().StringVar(&checkOpts.selector, "selector", checkOpts.selector, "Comma separated names and glob patterns of the nodes to check, defaulting to all running nodes.")
+	NetworkCheckCommand.Flags().Uint64Var(&checkOpts.maxLag, "max-lag", checkOpts.maxLag, "Number of blocks a node may lag behind the others on each chain.")
+	NetworkCheckCommand.Flags().StringVar(&checkOpts.save, "save", checkOpts.save, "Variable to save the state of each node to as JSON, as scope.var.")
+	NetworkCheckCommand.Flags().BoolVar(&checkOpts.exitOnFailure, "exit-on-failure", checkOpts.exitOnFailure, "Stop every process and exit the shell with status 1 if the check fails.")
+}
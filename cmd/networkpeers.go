@@ -0,0 +1,285 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var peersOpts struct {
+	format   string
+	selector string
+	minPeers int
+	save     string
+}
+
+// peerGraph is the connectivity graph of running nodes
+type peerGraph struct {
+	Nodes []peerGraphNode `json:"nodes"`
+	// Asymmetric holds the [from, to] pairs of nodes where only `from` lists
+	// `to` as a peer
+	Asymmetric [][2]string `json:"asymmetric"`
+}
+
+// peerGraphNode is a node of a peerGraph and its connections
+type peerGraphNode struct {
+	Name string `json:"name"`
+	// Peers holds the names of the peers which are managed processes, or else
+	// their IPs and node IDs
+	Peers []string `json:"peers"`
+	// Missing holds the other graph nodes it isn't connected to
+	Missing  []string `json:"missing"`
+	TooFew   bool     `json:"tooFew"`
+	Error    string   `json:"error,omitempty"`
+	numPeers int
+}
+
+// NetworkPeersCommand shows how running nodes are connected to each other
+var NetworkPeersCommand = &cobra.Command{
+	Use:   "peers",
+	Short: "Shows how running nodes are connected to each other.",
+	Long: `Shows how the running nodes matched by --selector, a comma separated list of
+	names and glob patterns defaulting to all of them, are connected to each
+	other. Peers are mapped back to process names by their staking IPs and node
+	IDs. Nodes with fewer peers than --min-peers, which defaults to the number
+	of other matched nodes, and connections only one side lists are flagged.
+
+	--format is one of table, dot and json. The dot output can be rendered with
+	Graphviz, where flagged nodes and connections are drawn in red.`,
+	Example: `network peers --format dot`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetPeersOpts(cmd)
+		log := cfg.Config.Log
+		names, err := selectNodes(peersOpts.selector)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		minPeers := peersOpts.minPeers
+		if minPeers < 0 {
+			minPeers = len(names) - 1
+		}
+		graph := buildPeerGraph(names, minPeers)
+		out := AvalancheShell.rl.Stdout()
+		switch peersOpts.format {
+		case "table":
+			printPeerTable(graph)
+		case "dot":
+			fmt.Fprint(out, peerGraphDot(graph))
+		case "json":
+			b, err := json.MarshalIndent(graph, "", "  ")
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			fmt.Fprintln(out, string(b))
+		default:
+			log.Error("unknown format %s, expected table, dot or json", peersOpts.format)
+			return
+		}
+		connected := len(graph.Asymmetric) == 0
+		for _, n := range graph.Nodes {
+			switch {
+			case n.Error != "":
+				log.Warn("%s failed to report peers: %s", n.Name, n.Error)
+			case n.TooFew:
+				log.Warn("%s has %d peers, expected at least %d", n.Name, n.numPeers, minPeers)
+			default:
+				continue
+			}
+			connected = false
+		}
+		for _, pair := range graph.Asymmetric {
+			log.Warn("%s lists %s as a peer, but not the other way around", pair[0], pair[1])
+		}
+		saveResult(peersOpts.save, graph)
+		log.Info("Connected: %t", connected)
+	},
+}
+
+// buildPeerGraph queries the peers of each of the named nodes and connects them
+func buildPeerGraph(names []string, minPeers int) peerGraph {
+	addrs := processStakingAddrs(runningNodes())
+	ids := processNodeIDs()
+	graph := peerGraph{Nodes: make([]peerGraphNode, len(names))}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(n *peerGraphNode, name string) {
+			defer wg.Done()
+			n.Name = name
+			peers, err := nodePeers(name)
+			if err != nil {
+				n.Error = err.Error()
+				return
+			}
+			for _, p := range peers {
+				n.Peers = append(n.Peers, peerName(p, addrs, ids))
+			}
+			sort.Strings(n.Peers)
+			n.numPeers = len(peers)
+			n.TooFew = n.numPeers < minPeers
+		}(&graph.Nodes[i], name)
+	}
+	wg.Wait()
+
+	lists := make(map[string]map[string]bool)
+	for _, n := range graph.Nodes {
+		lists[n.Name] = make(map[string]bool)
+		for _, p := range n.Peers {
+			lists[n.Name][p] = true
+		}
+	}
+	for i := range graph.Nodes {
+		n := &graph.Nodes[i]
+		for _, other := range names {
+			if other != n.Name && !lists[n.Name][other] {
+				n.Missing = append(n.Missing, other)
+			}
+		}
+		if n.Error != "" {
+			continue
+		}
+		for _, p := range n.Peers {
+			if peer, ok := lists[p]; ok && !peer[n.Name] && graph.Nodes[indexOf(names, p)].Error == "" {
+				graph.Asymmetric = append(graph.Asymmetric, [2]string{n.Name, p})
+			}
+		}
+	}
+	return graph
+}
+
+// peerName returns the name of the process `p` is, or else its IP and node ID
+func peerName(p nodePeer, addrs map[string]string, ids map[string]string) string {
+	for _, ip := range []string{p.IP, p.PublicIP} {
+		if name, ok := addrs[ip]; ok {
+			return name
+		}
+	}
+	if name, ok := ids[p.NodeID]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", p.IP, p.NodeID)
+}
+
+// processStakingAddrs maps the staking addresses of the named processes to
+// their names
+func processStakingAddrs(names []string) map[string]string {
+	addrs := make(map[string]string)
+	for _, name := range names {
+		md, err := nodeMetadata(name)
+		if err != nil {
+			continue
+		}
+		addrs[net.JoinHostPort(md.Serverhost, md.Stakingport)] = name
+	}
+	return addrs
+}
+
+// printPeerTable prints a row per node of `graph`
+func printPeerTable(graph peerGraph) {
+	table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+	table.SetHeader([]string{"Node", "Peers", "Connected To", "Missing"})
+	table.SetBorder(false)
+	for _, n := range graph.Nodes {
+		count := fmt.Sprint(n.numPeers)
+		if n.Error != "" {
+			count = "error"
+		} else if n.TooFew {
+			count += " (too few)"
+		}
+		table.Append([]string{
+			n.Name,
+			count,
+			strings.Join(n.Peers, "\n"),
+			strings.Join(n.Missing, "\n"),
+		})
+	}
+	table.Render()
+}
+
+// peerGraphDot renders `graph` in the Graphviz dot language. Mutual
+// connections are drawn as one edge with arrows at both ends.
+func peerGraphDot(graph peerGraph) string {
+	asymmetric := make(map[[2]string]bool)
+	for _, pair := range graph.Asymmetric {
+		asymmetric[pair] = true
+	}
+	var b strings.Builder
+	b.WriteString("digraph peers {\n")
+	for _, n := range graph.Nodes {
+		if n.Error != "" || n.TooFew {
+			fmt.Fprintf(&b, "\t%q [color=red];\n", n.Name)
+		} else {
+			fmt.Fprintf(&b, "\t%q;\n", n.Name)
+		}
+	}
+	drawn := make(map[[2]string]bool)
+	for _, n := range graph.Nodes {
+		for _, p := range n.Peers {
+			edge := [2]string{n.Name, p}
+			if drawn[edge] {
+				continue
+			}
+			switch {
+			case asymmetric[edge]:
+				fmt.Fprintf(&b, "\t%q -> %q [color=red];\n", n.Name, p)
+			case indexOfNode(graph, p) >= 0:
+				fmt.Fprintf(&b, "\t%q -> %q [dir=both];\n", n.Name, p)
+				drawn[[2]string{p, n.Name}] = true
+			default:
+				fmt.Fprintf(&b, "\t%q -> %q;\n", n.Name, p)
+			}
+			drawn[edge] = true
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// indexOfNode returns the index of the node named `name` in `graph`, or -1
+func indexOfNode(graph peerGraph, name string) int {
+	for i, n := range graph.Nodes {
+		if n.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOf returns the index of `s` in `list`, or -1
+func indexOf(list []string, s string) int {
+	for i, elem := range list {
+		if elem == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func resetPeersOpts(cmd *cobra.Command) {
+	peersOpts.format = "table"
+	peersOpts.selector = ""
+	peersOpts.minPeers = -1
+	peersOpts.save = ""
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetPeersOpts(NetworkPeersCommand)
+	NetworkCommand.AddCommand(NetworkPeersCommand)
+	NetworkPeersCommand.Flags().StringVar(&peersOpts.format, "format", peersOpts.format, "Output format: table, dot or json.")
+	NetworkPeersCommand.Flags().StringVar(&peersOpts.selector, "selector", peersOpts.selector, "Comma separated names and glob patterns of the nodes to show, defaulting to all running nodes.")
+	NetworkPeersCommand.Flags().IntVar(&peersOpts.minPeers, "min-peers", peersOpts.minPeers, "Number of peers below which a node is flagged, defaulting to the number of other shown nodes.")
+	NetworkPeersCommand.Flags().StringVar(&peersOpts.save, "save", peersOpts.save, "Variable to save the graph to as JSON, as scope.var.")
+}
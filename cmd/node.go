@@ -0,0 +1,296 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/node"
+	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/ava-labs/avash/utils/archive"
+	"github.com/kennygrant/sanitize"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// stopTimeout is how long to wait for a node to exit before touching its data
+const stopTimeout = 30 * time.Second
+
+// NodeCmd represents the node command
+var NodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Tools for managing the data directories of nodes.",
+	Long: `Tools for managing the data directories of nodes. Using this command you
+	can snapshot, restore and wipe the database and logs of a node, and show the
+	disk usage of all nodes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// NodeSnapshotCmd archives the data of a node under a tag
+var NodeSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [node name] [tag]",
+	Short: "Snapshots the database and logs of a node.",
+	Long: `Snapshots the database and logs of a node under the tag. A running node is
+	stopped while its data is archived, then restarted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name, tag := args[0], sanitize.BaseName(args[1])
+		if flags, err := pmgr.ProcManager.NodeFlags(name); err == nil && flags.DBType == "memdb" {
+			log.Warn("%s uses memdb, its database isn't persisted to disk", name)
+		}
+		paths, err := nodeDataPaths(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		snapshot := nodeSnapshotPath(name, tag)
+		err = withNodeStopped(name, func() error {
+			return archive.Create(snapshot, nodeDataPath(name), paths)
+		})
+		if err != nil {
+			log.Error("unable to snapshot %s: %s", name, err.Error())
+			return
+		}
+		log.Info("Snapshot of %s saved to: %s", name, snapshot)
+	},
+}
+
+// NodeRestoreCmd restores the data of a node from a tagged snapshot
+var NodeRestoreCmd = &cobra.Command{
+	Use:   "restore [node name] [tag]",
+	Short: "Restores the database and logs of a node from a snapshot.",
+	Long: `Restores the database and logs of a node from the snapshot under the tag,
+	replacing its current data. A running node is stopped during the restore, then
+	restarted. If the snapshot can't be read, the current data is kept and the
+	node is left stopped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name, tag := args[0], sanitize.BaseName(args[1])
+		snapshot := nodeSnapshotPath(name, tag)
+		if _, err := os.Stat(snapshot); err != nil {
+			log.Error("snapshot not found: %s", snapshot)
+			return
+		}
+		paths, err := nodeDataPaths(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		datapath := nodeDataPath(name)
+		err = withNodeStopped(name, func() error {
+			return restoreNodeData(snapshot, datapath, paths)
+		})
+		if err != nil {
+			log.Error("unable to restore %s: %s", name, err.Error())
+			return
+		}
+		log.Info("Restored %s from snapshot: %s", name, tag)
+	},
+}
+
+// NodeWipeCmd removes the data of a node
+var NodeWipeCmd = &cobra.Command{
+	Use:   "wipe [node name]",
+	Short: "Removes the database and logs of a node.",
+	Long: `Removes the database and logs of a node. A running node is stopped while
+	its data is removed, then restarted with empty state. Snapshots are kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name := args[0]
+		paths, err := nodeDataPaths(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		datapath := nodeDataPath(name)
+		err = withNodeStopped(name, func() error {
+			return removeNodeData(datapath, paths)
+		})
+		if err != nil {
+			log.Error("unable to wipe %s: %s", name, err.Error())
+			return
+		}
+		log.Info("Wiped data of %s", name)
+	},
+}
+
+// NodeDiskUsageCmd shows the disk usage of all nodes
+var NodeDiskUsageCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Shows the disk usage of all nodes.",
+	Long:  `Shows the disk usage of the database, logs and snapshots of all nodes in tabular format.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log := cfg.Config.Log
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Name", "DB", "Logs", "Snapshots", "Total"})
+		table.SetBorder(false)
+		for _, name := range pmgr.ProcManager.Names() {
+			md, err := nodeMetadata(name)
+			if err != nil {
+				log.Debug(err.Error())
+				continue
+			}
+			db := archive.Size(md.Dbdir)
+			logs := archive.Size(md.Logsdir)
+			snapshots := archive.Size(filepath.Dir(nodeSnapshotPath(name, "")))
+			table.Append([]string{
+				name,
+				formatBytes(db),
+				formatBytes(logs),
+				formatBytes(snapshots),
+				formatBytes(db + logs + snapshots),
+			})
+		}
+		table.Render()
+	},
+}
+
+// nodeDataPath returns the directory holding the data of the named node
+func nodeDataPath(name string) string {
+	return sanitize.Path(cfg.Config.DataDir + "/" + sanitize.BaseName(name))
+}
+
+// nodeSnapshotPath returns the location of the named node's snapshot under `tag`
+func nodeSnapshotPath(name string, tag string) string {
+	return filepath.Join(cfg.Config.DataDir, "snapshots", sanitize.BaseName(name), tag+".tar.gz")
+}
+
+// nodeMetadata returns the parsed metadata of the named node
+func nodeMetadata(name string) (node.Metadata, error) {
+	var md node.Metadata
+	meta, err := pmgr.ProcManager.Metadata(name)
+	if err != nil {
+		return md, err
+	}
+	if err := json.Unmarshal([]byte(meta), &md); err != nil {
+		return md, fmt.Errorf("unable to unmarshal metadata for node %s: %s", name, err.Error())
+	}
+	return md, nil
+}
+
+// nodeDataPaths returns the database and log directories of the named node,
+// relative to its data directory
+func nodeDataPaths(name string) ([]string, error) {
+	md, err := nodeMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+	datapath := nodeDataPath(name)
+	var paths []string
+	for _, dir := range []string{md.Dbdir, md.Logsdir} {
+		rel, err := filepath.Rel(datapath, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("%s is outside of the data directory of %s", dir, name)
+		}
+		paths = append(paths, rel)
+	}
+	return paths, nil
+}
+
+// withNodeStopped runs `f` while the named node is stopped, restarting the
+// node afterwards if it was running and `f` succeeded
+func withNodeStopped(name string, f func() error) error {
+	running, err := pmgr.ProcManager.IsRunning(name)
+	if err != nil {
+		return err
+	}
+	if running {
+		if err := pmgr.ProcManager.StopProcessAndWait(name, stopTimeout); err != nil {
+			return err
+		}
+	}
+	if err := f(); err != nil {
+		if running {
+			return fmt.Errorf("%s, leaving %s stopped", err.Error(), name)
+		}
+		return err
+	}
+	if running {
+		if err := pmgr.ProcManager.StartProcess(name); err != nil {
+			return fmt.Errorf("unable to restart %s: %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+// restoreNodeData replaces `paths` under `datapath` with their contents in the
+// `snapshot` archive. The archive is extracted next to `datapath` first, so
+// the current data is only replaced once the whole archive was read.
+func restoreNodeData(snapshot string, datapath string, paths []string) error {
+	tmp, err := ioutil.TempDir(filepath.Dir(datapath), filepath.Base(datapath)+".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := archive.Extract(snapshot, tmp); err != nil {
+		return err
+	}
+	if err := removeNodeData(datapath, paths); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		src := filepath.Join(tmp, p)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		dst := filepath.Join(datapath, p)
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.Rename(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeNodeData(datapath string, paths []string) error {
+	for _, p := range paths {
+		if err := os.RemoveAll(filepath.Join(datapath, p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	NodeCmd.AddCommand(NodeDiskUsageCmd)
+	NodeCmd.AddCommand(NodeRestoreCmd)
+	NodeCmd.AddCommand(NodeSnapshotCmd)
+	NodeCmd.AddCommand(NodeWipeCmd)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/spf13/cobra"
+)
+
+// PartitionCmd represents the partition command
+var PartitionCmd = &cobra.Command{
+	Use:   "partition",
+	Short: "Partitions the network between nodes.",
+	Long: `Partitions the network between nodes started with --proxied. See "link" for
+	how their connections are proxied.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// PartitionCreateCmd splits nodes into partitions
+var PartitionCreateCmd = &cobra.Command{
+	Use:   "create [node names] | [node names]...",
+	Short: "Splits nodes into partitions which can't reach each other.",
+	Long: `Splits nodes into partitions, given as comma separated node names separated
+	by "|". The connections between nodes in different partitions are cut and
+	new ones are refused until the partition is healed. Nodes in no partition
+	stay connected to every node. Replaces the previous partition, if any.`,
+	Example: `partition create n1,n2 | n3,n4,n5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log := cfg.Config.Log
+		var groups [][]string
+		for _, part := range strings.Split(strings.Join(args, " "), "|") {
+			var group []string
+			for _, name := range strings.Split(part, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					group = append(group, name)
+				}
+			}
+			if len(group) > 0 {
+				groups = append(groups, group)
+			}
+		}
+		if len(groups) < 2 {
+			cmd.Help()
+			return
+		}
+		for _, group := range groups {
+			if err := checkProxied(group...); err != nil {
+				log.Error(err.Error())
+				return
+			}
+		}
+		if err := linkProxies.Partition(groups); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var parts []string
+		for _, group := range groups {
+			parts = append(parts, strings.Join(group, ","))
+		}
+		log.Info("Partitioned %s", strings.Join(parts, " | "))
+	},
+}
+
+// PartitionHealCmd removes the partition
+var PartitionHealCmd = &cobra.Command{
+	Use:   "heal",
+	Short: "Reconnects partitioned nodes.",
+	Long: `Removes the partition. Nodes reconnect to each other through the proxies the
+	next time they try to.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		linkProxies.Heal()
+		cfg.Config.Log.Info("Partition healed")
+	},
+}
+
+func init() {
+	PartitionCmd.AddCommand(PartitionCreateCmd)
+	PartitionCmd.AddCommand(PartitionHealCmd)
+}
@@ -0,0 +1,171 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// platformValidator is a validator of a subnet as reported by the P-chain
+type platformValidator struct {
+	NodeID      string          `json:"nodeID"`
+	StartTime   avajson.Uint64  `json:"startTime"`
+	EndTime     avajson.Uint64  `json:"endTime"`
+	Weight      *avajson.Uint64 `json:"weight,omitempty"`
+	StakeAmount *avajson.Uint64 `json:"stakeAmount,omitempty"`
+	Connected   *bool           `json:"connected,omitempty"`
+}
+
+// PlatformCmd represents the platform command
+var PlatformCmd = &cobra.Command{
+	Use:   "platform",
+	Short: "Queries the P-chain API of a node.",
+	Long: `Queries the P-chain API of the node given by --node, defaulting to the first
+	running node. Results are printed as "Key: value" lines or tables, and the
+	raw response is saved as JSON to the variable given by --save.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// PlatformValidatorsCmd lists the current validators of a subnet
+var PlatformValidatorsCmd = &cobra.Command{
+	Use:   "validators [subnet]",
+	Short: "Lists the current validators of a subnet.",
+	Long: `Lists the current validators of a subnet, given by ID or by a name saved by
+	subnet create, defaulting to the primary network.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var params platformvm.GetCurrentValidatorsArgs
+		if len(args) > 0 {
+			subnetID, err := resolveID(subnetStore, args[0])
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			params.SubnetID = subnetID
+		}
+		var reply struct {
+			Validators []platformValidator `json:"validators"`
+		}
+		if err := apiClient.Call(name, "ext/P", "platform.getCurrentValidators", params, &reply); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		names := processNodeIDs()
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Node ID", "Process", "Weight", "Start", "End", "Connected"})
+		table.SetBorder(false)
+		for _, v := range reply.Validators {
+			weight, connected := "", ""
+			if v.Weight != nil {
+				weight = fmt.Sprint(uint64(*v.Weight))
+			} else if v.StakeAmount != nil {
+				weight = fmt.Sprint(uint64(*v.StakeAmount))
+			}
+			if v.Connected != nil {
+				connected = fmt.Sprint(*v.Connected)
+			}
+			table.Append([]string{
+				v.NodeID,
+				names[v.NodeID],
+				weight,
+				time.Unix(int64(v.StartTime), 0).Format(time.RFC3339),
+				time.Unix(int64(v.EndTime), 0).Format(time.RFC3339),
+				connected,
+			})
+		}
+		table.Render()
+		log.Info("NumValidators: %d", len(reply.Validators))
+		saveResult(apiOpts.save, reply.Validators)
+	},
+}
+
+// PlatformHeightCmd prints the P-chain height of a node
+var PlatformHeightCmd = &cobra.Command{
+	Use:   "height",
+	Short: "Prints the height of the last accepted P-chain block.",
+	Long:  `Prints the height of the last accepted P-chain block.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		height, err := platformHeight(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Height: %d", height)
+		saveResult(apiOpts.save, fmt.Sprint(height))
+	},
+}
+
+// PlatformSubnetsCmd lists the subnets
+var PlatformSubnetsCmd = &cobra.Command{
+	Use:   "subnets",
+	Short: "Lists the subnets.",
+	Long:  `Lists the subnets with their control keys and signature thresholds.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetAPIOpts(cmd)
+		log := cfg.Config.Log
+		name, err := defaultNode(apiOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var reply platformvm.GetSubnetsResponse
+		if err := apiClient.Call(name, "ext/P", "platform.getSubnets", platformvm.GetSubnetsArgs{}, &reply); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Subnet ID", "Threshold", "Control Keys"})
+		table.SetBorder(false)
+		for _, subnet := range reply.Subnets {
+			table.Append([]string{
+				subnet.ID.String(),
+				fmt.Sprint(uint32(subnet.Threshold)),
+				strings.Join(subnet.ControlKeys, "\n"),
+			})
+		}
+		table.Render()
+		log.Info("NumSubnets: %d", len(reply.Subnets))
+		saveResult(apiOpts.save, reply.Subnets)
+	},
+}
+
+// platformHeight returns the height of the last accepted P-chain block of
+// the named node
+func platformHeight(name string) (uint64, error) {
+	var reply platformvm.GetHeightResponse
+	if err := apiClient.Call(name, "ext/P", "platform.getHeight", struct{}{}, &reply); err != nil {
+		return 0, err
+	}
+	return uint64(reply.Height), nil
+}
+
+func init() {
+	addAPIFlags(PlatformCmd)
+	PlatformCmd.AddCommand(PlatformValidatorsCmd)
+	PlatformCmd.AddCommand(PlatformHeightCmd)
+	PlatformCmd.AddCommand(PlatformSubnetsCmd)
+}
@@ -4,10 +4,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"strconv"
 	"time"
 
 	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/node"
 	pmgr "github.com/ava-labs/avash/processmgr"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -57,6 +59,38 @@ var PMMetadataCmd = &cobra.Command{
 	},
 }
 
+// PMConfigCmd represents the config operation on the procmanager command
+var PMConfigCmd = &cobra.Command{
+	Use:   "config [node name]",
+	Short: "Prints the effective config of the node name.",
+	Long: `Prints the effective avalanchego config of the node name, combining its 
+	config file, if any, with its command line args.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) >= 1 && args[0] != "" {
+			log := cfg.Config.Log
+			name := args[0]
+			nodeArgs, err := pmgr.ProcManager.Args(name)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			config, err := node.ArgsToConfig(nodeArgs)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			configBytes, err := json.MarshalIndent(config, "", "    ")
+			if err != nil {
+				log.Error("unable to marshal config: %s", err.Error())
+				return
+			}
+			log.Info(string(configBytes))
+		} else {
+			cmd.Help()
+		}
+	},
+}
+
 // PMStartCmd represents the start operation on the procmanager command
 var PMStartCmd = &cobra.Command{
 	Use:   "start [node name] [optional: delay in secs]",
@@ -220,7 +254,9 @@ var PMRemoveCmd = &cobra.Command{
 			err := pmgr.ProcManager.RemoveProcess(name)
 			if err != nil {
 				log.Error(err.Error())
+				return
 			}
+			linkProxies.Remove(name)
 		}
 		delayRun(remove, delay)
 	},
@@ -240,7 +276,14 @@ var PMRemoveAllCmd = &cobra.Command{
 				log.Info("all processes will be removed in %ds", int(delay))
 			}
 		}
-		delayRun(pmgr.ProcManager.RemoveAllProcesses, delay)
+		removeAll := func() {
+			names := pmgr.ProcManager.Names()
+			pmgr.ProcManager.RemoveAllProcesses()
+			for _, name := range names {
+				linkProxies.Remove(name)
+			}
+		}
+		delayRun(removeAll, delay)
 	},
 }
 
@@ -257,6 +300,7 @@ func delayRun(f func(), delay time.Duration) {
 }
 
 func init() {
+	ProcmanagerCmd.AddCommand(PMConfigCmd)
 	ProcmanagerCmd.AddCommand(PMKillCmd)
 	ProcmanagerCmd.AddCommand(PMKillAllCmd)
 	ProcmanagerCmd.AddCommand(PMListCmd)
@@ -0,0 +1,92 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/node"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// ProfileCmd represents the profile command
+var ProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Shows the consensus profiles available to startnode.",
+	Long: `Shows the consensus profiles available to startnode's --profile flag. 
+	Profiles are either built in or defined under "profiles" in the config file, 
+	which take precedence over built-in profiles of the same name.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// ProfileListCmd lists all consensus profiles
+var ProfileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all consensus profiles.",
+	Long:  `Lists all consensus profiles with their effective snowball parameters.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Name", "Source", "K", "Alpha", "Beta1", "Beta2", "Repolls"})
+		table.SetBorder(false)
+		for _, name := range node.ProfileNames(cfg.Config.Profiles) {
+			source := "built-in"
+			if _, ok := cfg.Config.Profiles[name]; ok {
+				source = "config"
+			}
+			p, _ := node.LookupProfile(name, cfg.Config.Profiles)
+			e := p.Effective()
+			table.Append([]string{
+				name,
+				source,
+				strconv.Itoa(e.SnowSampleSize),
+				strconv.Itoa(e.SnowQuorumSize),
+				strconv.Itoa(e.SnowVirtuousCommitThreshold),
+				strconv.Itoa(e.SnowRogueCommitThreshold),
+				strconv.Itoa(e.SnowConcurrentRepolls),
+			})
+		}
+		table.Render()
+	},
+}
+
+// ProfileShowCmd shows the effective flags of a consensus profile
+var ProfileShowCmd = &cobra.Command{
+	Use:   "show [profile name]",
+	Short: "Shows the flags set by a consensus profile.",
+	Long:  `Shows the effective values of the startnode flags set by a consensus profile.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		p, err := node.LookupProfile(args[0], cfg.Config.Profiles)
+		if err != nil {
+			cfg.Config.Log.Error(err.Error())
+			return
+		}
+		e := p.Effective()
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Flag", "Value"})
+		table.SetBorder(false)
+		table.AppendBulk([][]string{
+			{"snow-sample-size", strconv.Itoa(e.SnowSampleSize)},
+			{"snow-quorum-size", strconv.Itoa(e.SnowQuorumSize)},
+			{"snow-virtuous-commit-threshold", strconv.Itoa(e.SnowVirtuousCommitThreshold)},
+			{"snow-rogue-commit-threshold", strconv.Itoa(e.SnowRogueCommitThreshold)},
+			{"snow-concurrent-repolls", strconv.Itoa(e.SnowConcurrentRepolls)},
+			{"snow-avalanche-batch-size", strconv.Itoa(e.SnowAvalancheBatchSize)},
+			{"snow-avalanche-num-parents", strconv.Itoa(e.SnowAvalancheNumParents)},
+		})
+		table.Render()
+	},
+}
+
+func init() {
+	ProfileCmd.AddCommand(ProfileListCmd)
+	ProfileCmd.AddCommand(ProfileShowCmd)
+}
@@ -6,7 +6,7 @@ package cmd
 
 import (
 	"os"
-	"strings"
+	"unicode"
 
 	"github.com/ava-labs/avash/cfg"
 	"github.com/chzyer/readline"
@@ -65,6 +65,43 @@ func completerFromRoot(c *cobra.Command) []readline.PrefixCompleterInterface {
 	return children
 }
 
+// splitArgs splits a command line into words on whitespace. A quote which
+// begins a word, or directly follows an '=', groups everything up to the
+// matching closing quote into the word. Other quotes are kept as-is so that
+// JSON arguments pass through untouched.
+func splitArgs(ln string) []string {
+	var args []string
+	var word []rune
+	inWord := false
+	var quote rune
+	for _, r := range ln {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word = append(word, r)
+			}
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, string(word))
+				word = word[:0]
+				inWord = false
+			}
+		case (r == '"' || r == '\'') && (!inWord || (len(word) > 0 && word[len(word)-1] == '=')):
+			quote = r
+			inWord = true
+		default:
+			word = append(word, r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, string(word))
+	}
+	return args
+}
+
 // ShellLoop is an execution loop for the terminal application
 func (sh *Shell) ShellLoop() {
 	rootPC := completerFromRoot(sh.root)
@@ -84,7 +121,7 @@ func (sh *Shell) ShellLoop() {
 		if err != nil {
 			continue
 		}
-		cmd, flags, err := sh.root.Find(strings.Fields(ln))
+		cmd, flags, err := sh.root.Find(splitArgs(ln))
 		if err != nil {
 			sh.rl.Terminal.Write([]byte(err.Error()))
 		}
@@ -124,14 +161,30 @@ func init() {
 	}
 
 	cfg.InitConfig(cfgpath)
+	RootCmd.AddCommand(AdminCmd)
+	RootCmd.AddCommand(AuthCmd)
 	RootCmd.AddCommand(AVAXWalletCmd)
 	RootCmd.AddCommand(CallRPCCmd)
+	RootCmd.AddCommand(EVMCmd)
 	RootCmd.AddCommand(ExitCmd)
+	RootCmd.AddCommand(HealthCmd)
+	RootCmd.AddCommand(InfoCmd)
+	RootCmd.AddCommand(KeystoreCmd)
+	RootCmd.AddCommand(LinkCmd)
+	RootCmd.AddCommand(LoadgenCmd)
+	RootCmd.AddCommand(MetricsCmd)
 	RootCmd.AddCommand(NetworkCommand)
+	RootCmd.AddCommand(NodeCmd)
+	RootCmd.AddCommand(PartitionCmd)
+	RootCmd.AddCommand(PlatformCmd)
 	RootCmd.AddCommand(ProcmanagerCmd)
+	RootCmd.AddCommand(ProfileCmd)
 	RootCmd.AddCommand(RunScriptCmd)
 	RootCmd.AddCommand(SetOutputCmd)
 	RootCmd.AddCommand(StartnodeCmd)
+	RootCmd.AddCommand(SubnetCmd)
+	RootCmd.AddCommand(TxCmd)
+	RootCmd.AddCommand(ValidatorCmd)
 	RootCmd.AddCommand(VarStoreCmd)
 	RootCmd.SetUsageTemplate(usageTmpl)
 
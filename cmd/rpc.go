@@ -0,0 +1,264 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/nodeapi"
+	pmgr "github.com/ava-labs/avash/processmgr"
+)
+
+const (
+	// pollInterval is how often to poll a node while waiting on a transaction
+	pollInterval = 500 * time.Millisecond
+
+	// defaultTxTimeout is how long to wait for a transaction to be decided
+	defaultTxTimeout = 30 * time.Second
+
+	// apiTimeout bounds each request to the API of a node
+	apiTimeout = 30 * time.Second
+
+	// apiRetries is how many times to retry a request to a node that couldn't
+	// be reached, which happens while it's starting up
+	apiRetries = 3
+)
+
+// primaryChain describes the API of one of the primary network chains
+type primaryChain struct {
+	endpoint     string
+	prefix       string
+	exportMethod string
+	importMethod string
+}
+
+// primaryChains maps the aliases of the primary network chains to their APIs
+var primaryChains = map[string]primaryChain{
+	"X": {"ext/bc/X", "avm", "avm.export", "avm.import"},
+	"P": {"ext/P", "platform", "platform.exportAVAX", "platform.importAVAX"},
+	"C": {"ext/bc/C/avax", "avax", "avax.export", "avax.import"},
+}
+
+// lookupChain returns the API of the primary network chain `alias`
+func lookupChain(alias string) (primaryChain, error) {
+	chain, ok := primaryChains[strings.ToUpper(alias)]
+	if !ok {
+		return primaryChain{}, fmt.Errorf("unknown chain %s, expected X, P or C", alias)
+	}
+	return chain, nil
+}
+
+// nodeTarget returns where the API of the named node is served
+func nodeTarget(name string) (nodeapi.Target, error) {
+	md, err := nodeMetadata(name)
+	if err != nil {
+		return nodeapi.Target{}, err
+	}
+	return nodeapi.Target{
+		Host:     md.Serverhost,
+		Port:     md.HTTPport,
+		TLS:      md.HTTPTLS,
+		CertFile: md.HTTPTLSCert,
+	}, nil
+}
+
+// authTokens holds the API auth tokens to send with requests, by node name
+var authTokens = make(map[string]string)
+
+// apiClient issues the requests to the APIs of nodes
+var apiClient *nodeapi.Client
+
+// nodeID returns the node ID of the named node
+func nodeID(name string) (string, error) {
+	var reply struct {
+		NodeID string `json:"nodeID"`
+	}
+	if err := apiClient.Call(name, "ext/info", "info.getNodeID", struct{}{}, &reply); err != nil {
+		return "", err
+	}
+	return reply.NodeID, nil
+}
+
+// defaultNode returns `name` if set, otherwise the first running process
+func defaultNode(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	if running := runningNodes(); len(running) > 0 {
+		return running[0], nil
+	}
+	return "", fmt.Errorf("no running node to send the request to")
+}
+
+// runningNodes returns the names of the running processes
+func runningNodes() []string {
+	var names []string
+	for _, n := range pmgr.ProcManager.Names() {
+		if running, err := pmgr.ProcManager.IsRunning(n); err == nil && running {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// selectNodes returns the running processes matched by `selector`, a comma
+// separated list of names and glob patterns, or all of them if it's empty
+func selectNodes(selector string) ([]string, error) {
+	running := runningNodes()
+	if selector == "" {
+		if len(running) == 0 {
+			return nil, fmt.Errorf("no running nodes")
+		}
+		return running, nil
+	}
+	var selected []string
+	for _, name := range running {
+		for _, pattern := range strings.Split(selector, ",") {
+			matched, err := path.Match(strings.TrimSpace(pattern), name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %s: %s", pattern, err.Error())
+			}
+			if matched {
+				selected = append(selected, name)
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no running node matches %s", selector)
+	}
+	return selected, nil
+}
+
+// waitForPlatformTx polls the named node until the P-chain transaction
+// `txID` is committed, returning an error if it's dropped or aborted
+func waitForPlatformTx(name string, txID ids.ID, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var reply platformvm.GetTxStatusResponse
+		err := apiClient.Call(name, "ext/P", "platform.getTxStatus", platformvm.GetTxStatusArgs{
+			TxID:          txID,
+			IncludeReason: true,
+		}, &reply)
+		if err != nil {
+			return err
+		}
+		switch reply.Status {
+		case platformvm.Committed:
+			return nil
+		case platformvm.Aborted, platformvm.Dropped:
+			return fmt.Errorf("transaction %s %s: %s", txID, reply.Status, reply.Reason)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for transaction %s, last status: %s", txID, reply.Status)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// waitForTx polls the named node until the transaction `txID` on the primary
+// network chain `alias` is accepted, returning an error if it's rejected
+func waitForTx(name string, alias string, txID ids.ID, timeout time.Duration) error {
+	alias = strings.ToUpper(alias)
+	if alias == "P" {
+		return waitForPlatformTx(name, txID, timeout)
+	}
+	status, err := pollTxStatus(name, alias, txID, timeout, pollInterval)
+	if err != nil {
+		return err
+	}
+	if status != "Accepted" {
+		return fmt.Errorf("transaction %s %s", txID, status)
+	}
+	return nil
+}
+
+// pollTxStatus polls the named node every `interval` until the transaction
+// `txID` on the X or C chain is decided, returning its final status
+func pollTxStatus(name string, alias string, txID ids.ID, timeout time.Duration, interval time.Duration) (string, error) {
+	chain, err := lookupChain(alias)
+	if err != nil {
+		return "", err
+	}
+	method := chain.prefix + ".getTxStatus"
+	if strings.ToUpper(alias) == "C" {
+		method = "avax.getAtomicTxStatus"
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		var reply struct {
+			Status string `json:"status"`
+		}
+		err := apiClient.Call(name, chain.endpoint, method, struct {
+			TxID ids.ID `json:"txID"`
+		}{txID}, &reply)
+		if err != nil {
+			return "", err
+		}
+		switch reply.Status {
+		case "Accepted", "Rejected", "Dropped":
+			return reply.Status, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for transaction %s, last status: %s", txID, reply.Status)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// recordVar sets `varname` in the variable store `scope`, creating the store
+// if it doesn't exist
+func recordVar(scope string, varname string, value string) {
+	store, err := AvashVars.Get(scope)
+	if err != nil {
+		AvashVars.Create(scope)
+		store, _ = AvashVars.Get(scope)
+	}
+	store.Set(varname, value)
+}
+
+// saveResult saves `v`, as JSON unless it's a string, to the variable `target`
+// given as scope.var, if it's set
+func saveResult(target string, v interface{}) {
+	if target == "" {
+		return
+	}
+	log := cfg.Config.Log
+	parts := strings.SplitN(target, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		log.Error("invalid variable %s, expected scope.var", target)
+		return
+	}
+	value, ok := v.(string)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			log.Error("unable to encode response: %s", err.Error())
+			return
+		}
+		value = string(b)
+	}
+	recordVar(parts[0], parts[1], value)
+	log.Info("Response saved to %q.%q", parts[0], parts[1])
+}
+
+func init() {
+	// Set here since minting auth tokens goes through apiClient itself
+	apiClient = nodeapi.New(nodeapi.Config{
+		Resolve:    nodeTarget,
+		Token:      nodeToken,
+		Refresh:    mintToken,
+		Timeout:    apiTimeout,
+		Retries:    apiRetries,
+		RetryDelay: pollInterval,
+		Log:        &cfg.Config.Log,
+	})
+}
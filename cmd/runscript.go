@@ -133,7 +133,7 @@ func AvashSetVar(L *lua.LState) int {
 // AvashCall hooks avash calls into scripts
 func AvashCall(L *lua.LState) int { /* returns number of results */
 	lv := L.ToString(1) /* get argument */
-	cmd, flags, err := AvalancheShell.root.Find(strings.Fields(lv))
+	cmd, flags, err := AvalancheShell.root.Find(splitArgs(lv))
 	if err != nil {
 		AvalancheShell.rl.Terminal.Write([]byte(err.Error()))
 	}
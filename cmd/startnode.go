@@ -5,8 +5,8 @@ package cmd
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/kennygrant/sanitize"
@@ -15,60 +15,84 @@ import (
 	"github.com/ava-labs/avash/node"
 	pmgr "github.com/ava-labs/avash/processmgr"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var flags node.Flags
 
+var fromNode string
+
+var profileName string
+
 // StartnodeCmd represents the startnode command
 var StartnodeCmd = &cobra.Command{
 	Use:   "startnode [node name] args...",
 	Short: "Starts a node process and gives it a name.",
 	Long: `Starts an Avalanche client node using pmgo and gives it a name. Example:
-	startnode MyNode1 --public-ip=127.0.0.1 --staking-port=9651 --http-port=9650 ... `,
+	startnode MyNode1 --public-ip=127.0.0.1 --staking-port=9651 --http-port=9650 ... 
+
+	Use --from to copy the flags of an existing node. Its ports and staking key 
+	pair are re-allocated, and any flags given explicitly take precedence:
+	startnode MyNode2 --from MyNode1 --log-level=debug
+
+	Use --profile to set the snowball parameters from a consensus profile. See 
+	"profile list" for the available profiles:
+	startnode MyNode3 --profile fast-local`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			cmd.Help()
 			return
 		}
+		defer func() {
+			// Set flags to default for next `startnode` call
+			flags = node.DefaultFlags()
+			fromNode = ""
+			profileName = ""
+			resetChangedFlags(cmd)
+		}()
 		log := cfg.Config.Log
 		name := args[0]
+		if _, err := pmgr.ProcManager.Metadata(name); err == nil {
+			log.Error("Process with name %s already exists", name)
+			return
+		}
 
-		datadir := cfg.Config.DataDir
-		basename := sanitize.BaseName(name)
-		datapath := datadir + "/" + basename
-		if basename == "" {
+		if sanitize.BaseName(name) == "" {
 			log.Error("Process name can't be empty")
 			return
 		}
+		datapath := nodeDataPath(name)
+
+		if fromNode != "" {
+			if err := cloneFlags(cmd, fromNode, datapath); err != nil {
+				log.Error(err.Error())
+				return
+			}
+			log.Info("Copied flags of %s: http-port=%d staking-port=%d", fromNode, flags.HTTPPort, flags.StakingPort)
+		}
 
-		err := validateConsensusArgs(
-			flags.SnowSampleSize,
-			flags.SnowQuorumSize,
-			flags.SnowVirtuousCommitThreshold,
-			flags.SnowRogueCommitThreshold,
-		)
-		if err != nil {
-			log.Error(err.Error())
+		if profileName != "" {
+			if err := applyProfile(cmd, profileName); err != nil {
+				log.Error(err.Error())
+				return
+			}
+		}
+
+		if flags.Binary != "" && flags.ClientLocation != "" {
+			log.Error("Only one of --binary and --client-location can be set")
 			return
 		}
 
-		args, md := node.FlagsToArgs(flags, sanitize.Path(datapath), false)
-		defer func() {
-			// Set flags to default for next `startnode` call
-			flags = node.DefaultFlags()
-		}()
-		mdbytes, _ := json.MarshalIndent(md, " ", "    ")
-		metadata := string(mdbytes)
-		meta := flags.Meta
-		if meta != "" {
-			metadata = meta
+		if err := node.Validate(flags); err != nil {
+			log.Error(err.Error())
+			return
 		}
-		avalancheLocation := flags.ClientLocation
-		if avalancheLocation == "" {
-			avalancheLocation = cfg.Config.AvalancheLocation
+
+		for _, f := range node.UnknownFlags(node.ExtraArgs(flags)) {
+			log.Warn("Forwarding unrecognized flag to %s: --%s", name, f)
 		}
-		err = pmgr.ProcManager.AddProcess(avalancheLocation, "avalanche node", args, name, metadata, nil, nil, nil)
-		if err != nil {
+
+		if err := addNodeProcess(name, flags); err != nil {
 			log.Error(err.Error())
 			return
 		}
@@ -77,34 +101,176 @@ var StartnodeCmd = &cobra.Command{
 	},
 }
 
-func validateConsensusArgs(k int, alpha int, beta1 int, beta2 int) error {
-	rulesfailed := []string(nil)
-	if k <= 0 {
-		rulesfailed = append(rulesfailed, "k > 0")
+// addNodeProcess adds a node process launched with `nodeFlags` to the
+// process manager under `name`, without starting it
+func addNodeProcess(name string, nodeFlags node.Flags) error {
+	log := cfg.Config.Log
+	avalancheLocation, err := binaryLocation(nodeFlags)
+	if err != nil {
+		return err
+	}
+	version, err := node.DetectVersion(avalancheLocation)
+	if err != nil {
+		log.Warn("Unable to detect avalanchego version, skipping flag compatibility checks: %s", err.Error())
+	}
+
+	datapath := nodeDataPath(name)
+	launchFlags := nodeFlags
+	if nodeFlags.Proxied {
+		if launchFlags, err = proxiedFlags(name, nodeFlags); err != nil {
+			return err
+		}
+	}
+	var args []string
+	var md node.Metadata
+	if nodeFlags.UseConfigFile {
+		args, md, err = node.FlagsToConfigArgs(launchFlags, datapath)
+		if err != nil {
+			return err
+		}
+	} else {
+		args, md = node.FlagsToArgs(launchFlags, datapath, false)
+	}
+	if !version.IsZero() {
+		var warnings []string
+		args, warnings = node.FilterArgs(args, version)
+		if md.ConfigFile != "" {
+			configWarnings, err := node.FilterConfigFile(md.ConfigFile, version)
+			if err != nil {
+				return err
+			}
+			warnings = append(warnings, configWarnings...)
+		}
+		for _, w := range warnings {
+			log.Warn("%s: %s", name, w)
+		}
+	}
+	mdbytes, _ := json.MarshalIndent(md, " ", "    ")
+	metadata := string(mdbytes)
+	meta := nodeFlags.Meta
+	if meta != "" {
+		metadata = meta
+	}
+	err = pmgr.ProcManager.AddProcess(avalancheLocation, "avalanche node", args, name, metadata, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	return pmgr.ProcManager.SetNodeFlags(name, nodeFlags)
+}
+
+// binaryLocation returns the path of the avalanchego binary to launch a node
+// with `nodeFlags` with, in order of precedence: the client location, the
+// named binary from the config file, then the config file's default location
+func binaryLocation(nodeFlags node.Flags) (string, error) {
+	if nodeFlags.ClientLocation != "" {
+		return nodeFlags.ClientLocation, nil
 	}
-	if alpha > k {
-		rulesfailed = append(rulesfailed, "alpha <= k")
+	if nodeFlags.Binary != "" {
+		// Viper lowercases the keys of maps in the config file
+		location, ok := cfg.Config.Binaries[strings.ToLower(nodeFlags.Binary)]
+		if !ok {
+			return "", fmt.Errorf("unknown binary: %s", nodeFlags.Binary)
+		}
+		return location, nil
 	}
-	if (k / 2) >= alpha {
-		rulesfailed = append(rulesfailed, "alpha > floor(k/2)")
+	return cfg.Config.AvalancheLocation, nil
+}
+
+// cloneFlags replaces `flags` with a clone of the node flags recorded for
+// `source`, then re-applies the flags explicitly passed to `cmd`
+func cloneFlags(cmd *cobra.Command, source string, datapath string) error {
+	sourceFlags, err := pmgr.ProcManager.NodeFlags(source)
+	if err != nil {
+		return err
 	}
-	if beta1 <= 0 {
-		rulesfailed = append(rulesfailed, "beta1 > 0")
+	explicit := changedFlags(cmd)
+	clone, err := node.Clone(sourceFlags, datapath, takenPorts())
+	if err != nil {
+		return err
 	}
-	if beta1 > beta2 {
-		rulesfailed = append(rulesfailed, "beta2 >= beta1")
+	flags = clone
+	return setFlags(cmd, explicit)
+}
+
+// applyProfile sets the snowball parameters in `flags` from the consensus
+// profile `name`, then re-applies the flags explicitly passed to `cmd`
+func applyProfile(cmd *cobra.Command, name string) error {
+	profile, err := node.LookupProfile(name, cfg.Config.Profiles)
+	if err != nil {
+		return err
 	}
-	if len(rulesfailed) == 0 {
-		return nil
+	if err := profile.Validate(); err != nil {
+		return err
 	}
-	return errors.New("Invalid consensus params: \n" + strings.Join(rulesfailed, "\n"))
+	explicit := changedFlags(cmd)
+	profile.Apply(&flags)
+	return setFlags(cmd, explicit)
+}
+
+// setFlags sets the node flags of `cmd` to `values`
+func setFlags(cmd *cobra.Command, values map[string]string) error {
+	for name, value := range values {
+		if name == "from" || name == "profile" {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changedFlags returns the values of the flags explicitly passed to `cmd`
+func changedFlags(cmd *cobra.Command) map[string]string {
+	changed := make(map[string]string)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			changed[f.Name] = f.Value.String()
+		}
+	})
+	return changed
+}
+
+// resetChangedFlags clears the changed state of the flags of `cmd`, which
+// otherwise persists between commands run in the same shell
+func resetChangedFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+	})
+}
+
+// takenPorts returns the HTTP and staking ports of all node processes
+func takenPorts() map[uint]bool {
+	taken := make(map[uint]bool)
+	for _, name := range pmgr.ProcManager.Names() {
+		meta, err := pmgr.ProcManager.Metadata(name)
+		if err != nil {
+			continue
+		}
+		var md node.Metadata
+		if err := json.Unmarshal([]byte(meta), &md); err != nil {
+			continue
+		}
+		for _, p := range []string{md.HTTPport, md.Stakingport} {
+			if port, err := strconv.ParseUint(p, 10, 16); err == nil {
+				taken[uint(port)] = true
+			}
+		}
+	}
+	return taken
 }
 
 func init() {
 	flags = node.DefaultFlags()
+	StartnodeCmd.Flags().StringVar(&fromNode, "from", fromNode, "Name of an existing node to copy the flags of. Ports and the staking key pair are re-allocated.")
+	StartnodeCmd.Flags().StringVar(&profileName, "profile", profileName, "Name of a consensus profile to set the snowball parameters from. Flags given explicitly take precedence.")
 	StartnodeCmd.Flags().StringVar(&flags.ClientLocation, "client-location", flags.ClientLocation, "Path to AVA node client, defaulting to the config file's value.")
+	StartnodeCmd.Flags().StringVar(&flags.Binary, "binary", flags.Binary, "Name of an avalanchego binary from the config file's `binaries` to run the node with.")
 	StartnodeCmd.Flags().StringVar(&flags.Meta, "meta", flags.Meta, "Override default metadata for the node process.")
 	StartnodeCmd.Flags().StringVar(&flags.DataDir, "data-dir", flags.DataDir, "Name of directory for the data stash.")
+	StartnodeCmd.Flags().StringVar(&flags.Group, "group", flags.Group, "Name of the group of nodes this node belongs to, used by network-wide commands.")
+	StartnodeCmd.Flags().BoolVar(&flags.Proxied, "proxied", flags.Proxied, "Connect to the nodes in --bootstrap-ips and every other running --proxied node through avash's proxies, so `partition` and `link` can inject faults into the connections.")
+	StartnodeCmd.Flags().BoolVar(&flags.UseConfigFile, "use-config-file", flags.UseConfigFile, "Render the node's flags into a JSON config file in its data directory and launch with `--config-file`. Values from an existing `--config-file` are merged in.")
 
 	StartnodeCmd.Flags().BoolVar(&flags.AssertionsEnabled, "assertions-enabled", flags.AssertionsEnabled, "Turn on assertion execution.")
 	StartnodeCmd.Flags().BoolVar(&flags.Version, "version", flags.Version, "If this is `true`, print the version and quit. Defaults to `false`")
@@ -221,4 +387,6 @@ func init() {
 	StartnodeCmd.Flags().BoolVar(&flags.IndexEnabled, "index-enabled", flags.IndexEnabled, "If true, index all accepted containers and transactions and expose them via an API")
 	StartnodeCmd.Flags().BoolVar(&flags.PluginModeEnabled, "plugin-mode-enabled", flags.PluginModeEnabled, "Whether the app should run as a plugin. Defaults to false.")
 	StartnodeCmd.Flags().BoolVar(&flags.MeterVMsEnabled, "meter-vms-enabled", flags.MeterVMsEnabled, "Whether the MeterVMs should be enabled on each VM.")
+
+	StartnodeCmd.Flags().StringVar(&flags.ExtraArgs, "extra-args", flags.ExtraArgs, "Additional flags forwarded verbatim to the node, for options avash doesn't model. Ex: --extra-args \"--foo=bar --baz\"")
 }
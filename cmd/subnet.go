@@ -0,0 +1,380 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// subnetStore is the variable store recording the IDs of created subnets
+	subnetStore = "subnets"
+
+	// chainStore is the variable store recording the IDs of created blockchains
+	chainStore = "chains"
+)
+
+var subnetOpts struct {
+	node        string
+	username    string
+	password    string
+	name        string
+	controlKeys string
+	threshold   uint32
+	weight      uint64
+	start       time.Duration
+	duration    time.Duration
+	vmID        string
+	fxIDs       string
+	genesis     string
+}
+
+// SubnetCmd represents the subnet command
+var SubnetCmd = &cobra.Command{
+	Use:   "subnet",
+	Short: "Tools for creating subnets and their blockchains.",
+	Long: `Tools for creating subnets and their blockchains. Transactions are issued
+	and paid for by a keystore user on the node given by --node, defaulting to the
+	first running node. Created subnet and blockchain IDs are recorded under their
+	names in the "subnets" and "chains" variable stores, and those names can be
+	used wherever a subnet ID is expected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// SubnetCreateCmd creates a subnet
+var SubnetCreateCmd = &cobra.Command{
+	Use:   "create --control-keys [addresses] --threshold [n]",
+	Short: "Creates a subnet.",
+	Long: `Creates a subnet controlled by the comma separated P-chain addresses given
+	by --control-keys, and waits for it to be committed.`,
+	Example: `subnet create --user u --password p --control-keys P-local18jma8ppw3nhx5r4ap8clazz0dps7rv5u00z96u --name mysubnet`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetSubnetOpts(cmd)
+		log := cfg.Config.Log
+		if subnetOpts.controlKeys == "" {
+			cmd.Help()
+			return
+		}
+		name, err := defaultNode(subnetOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var reply api.JSONTxIDChangeAddr
+		err = apiClient.Call(name, "ext/P", "platform.createSubnet", platformvm.CreateSubnetArgs{
+			JSONSpendHeader: spendHeader(),
+			APISubnet: platformvm.APISubnet{
+				ControlKeys: strings.Split(subnetOpts.controlKeys, ","),
+				Threshold:   json.Uint32(subnetOpts.threshold),
+			},
+		}, &reply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := waitForPlatformTx(name, reply.TxID, defaultTxTimeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		varname := subnetOpts.name
+		if varname == "" {
+			varname = reply.TxID.String()
+		}
+		recordVar(subnetStore, varname, reply.TxID.String())
+		log.Info("SubnetID: %s", reply.TxID)
+		log.Info("Subnet ID saved to %q.%q", subnetStore, varname)
+	},
+}
+
+// SubnetAddValidatorCmd adds a node as a validator of a subnet
+var SubnetAddValidatorCmd = &cobra.Command{
+	Use:   "add-validator [subnet] [node name]",
+	Short: "Adds a node as a validator of a subnet.",
+	Long: `Adds a node as a validator of a subnet. The node must already validate the
+	primary network for the whole validation period, and the keystore user must
+	control enough of the subnet's control keys to sign the transaction.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetSubnetOpts(cmd)
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		subnetID, err := resolveID(subnetStore, args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		id, err := nodeID(args[1])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		name := subnetOpts.node
+		if name == "" {
+			name = args[1]
+		}
+		start := time.Now().Add(subnetOpts.start)
+		weight := json.Uint64(subnetOpts.weight)
+		var reply api.JSONTxIDChangeAddr
+		err = apiClient.Call(name, "ext/P", "platform.addSubnetValidator", platformvm.AddSubnetValidatorArgs{
+			JSONSpendHeader: spendHeader(),
+			APIStaker: platformvm.APIStaker{
+				NodeID:    id,
+				StartTime: json.Uint64(start.Unix()),
+				EndTime:   json.Uint64(start.Add(subnetOpts.duration).Unix()),
+				Weight:    &weight,
+			},
+			SubnetID: subnetID.String(),
+		}, &reply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := waitForPlatformTx(name, reply.TxID, defaultTxTimeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Added %s (%s) as a validator of subnet %s starting %s", args[1], id, subnetID, start.Format(time.RFC3339))
+	},
+}
+
+// SubnetCreateChainCmd creates a blockchain validated by a subnet
+var SubnetCreateChainCmd = &cobra.Command{
+	Use:   "create-chain [subnet] --vm [vm ID] --genesis [file]",
+	Short: "Creates a blockchain validated by a subnet.",
+	Long: `Creates a blockchain running the VM given by --vm, validated by the subnet,
+	from the genesis bytes in the file given by --genesis. Nodes validating the
+	subnet must whitelist it with --whitelisted-subnets and have the VM installed
+	in their plugin directory to run the blockchain.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetSubnetOpts(cmd)
+		if len(args) < 1 || subnetOpts.vmID == "" || subnetOpts.genesis == "" {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		subnetID, err := resolveID(subnetStore, args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		genesis, err := ioutil.ReadFile(subnetOpts.genesis)
+		if err != nil {
+			log.Error("unable to read genesis file: %s", err.Error())
+			return
+		}
+		genesisData, err := formatting.EncodeWithChecksum(formatting.Hex, genesis)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		name, err := defaultNode(subnetOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		chainName := subnetOpts.name
+		if chainName == "" {
+			chainName = subnetOpts.vmID
+		}
+		var fxIDs []string
+		if subnetOpts.fxIDs != "" {
+			fxIDs = strings.Split(subnetOpts.fxIDs, ",")
+		}
+		var reply api.JSONTxIDChangeAddr
+		err = apiClient.Call(name, "ext/P", "platform.createBlockchain", platformvm.CreateBlockchainArgs{
+			JSONSpendHeader: spendHeader(),
+			SubnetID:        subnetID,
+			VMID:            subnetOpts.vmID,
+			FxIDs:           fxIDs,
+			Name:            chainName,
+			GenesisData:     genesisData,
+			Encoding:        formatting.Hex,
+		}, &reply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := waitForPlatformTx(name, reply.TxID, defaultTxTimeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		recordVar(chainStore, chainName, reply.TxID.String())
+		log.Info("BlockchainID: %s", reply.TxID)
+		log.Info("Blockchain ID saved to %q.%q", chainStore, chainName)
+	},
+}
+
+// SubnetStatusCmd shows the validators and blockchains of a subnet
+var SubnetStatusCmd = &cobra.Command{
+	Use:   "status [subnet]",
+	Short: "Shows the validators and blockchains of a subnet.",
+	Long: `Shows the control keys, current validators and blockchains of a subnet,
+	with the status of each blockchain on the node given by --node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetSubnetOpts(cmd)
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		subnetID, err := resolveID(subnetStore, args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		name, err := defaultNode(subnetOpts.node)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		var subnets platformvm.GetSubnetsResponse
+		err = apiClient.Call(name, "ext/P", "platform.getSubnets", platformvm.GetSubnetsArgs{
+			IDs: []ids.ID{subnetID},
+		}, &subnets)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if len(subnets.Subnets) == 0 {
+			log.Error("subnet not found: %s", subnetID)
+			return
+		}
+		subnet := subnets.Subnets[0]
+		log.Info("Subnet: %s", subnetID)
+		log.Info("Control keys (threshold %d): %s", subnet.Threshold, strings.Join(subnet.ControlKeys, ", "))
+
+		var validators struct {
+			Validators []platformvm.APIStaker `json:"validators"`
+		}
+		err = apiClient.Call(name, "ext/P", "platform.getCurrentValidators", platformvm.GetCurrentValidatorsArgs{
+			SubnetID: subnetID,
+		}, &validators)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Validator", "Weight", "Start", "End"})
+		table.SetBorder(false)
+		for _, v := range validators.Validators {
+			weight := ""
+			if v.Weight != nil {
+				weight = fmt.Sprint(uint64(*v.Weight))
+			}
+			table.Append([]string{
+				v.NodeID,
+				weight,
+				time.Unix(int64(v.StartTime), 0).Format(time.RFC3339),
+				time.Unix(int64(v.EndTime), 0).Format(time.RFC3339),
+			})
+		}
+		table.Render()
+
+		var chains platformvm.GetBlockchainsResponse
+		if err := apiClient.Call(name, "ext/P", "platform.getBlockchains", struct{}{}, &chains); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		table = tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Blockchain", "Name", "VM", "Status"})
+		table.SetBorder(false)
+		for _, c := range chains.Blockchains {
+			if c.SubnetID != subnetID {
+				continue
+			}
+			var status platformvm.GetBlockchainStatusReply
+			err := apiClient.Call(name, "ext/P", "platform.getBlockchainStatus", platformvm.GetBlockchainStatusArgs{
+				BlockchainID: c.ID.String(),
+			}, &status)
+			statusStr := status.Status.String()
+			if err != nil {
+				statusStr = err.Error()
+			}
+			table.Append([]string{c.ID.String(), c.Name, c.VMID.String(), statusStr})
+		}
+		table.Render()
+	},
+}
+
+// spendHeader returns the keystore credentials used to pay for transactions
+func spendHeader() api.JSONSpendHeader {
+	return api.JSONSpendHeader{
+		UserPass: api.UserPass{
+			Username: subnetOpts.username,
+			Password: subnetOpts.password,
+		},
+	}
+}
+
+// resolveID parses `s` as an ID, or looks it up as a variable name in the
+// variable store `scope`
+func resolveID(scope string, s string) (ids.ID, error) {
+	if store, err := AvashVars.Get(scope); err == nil {
+		if v, err := store.Get(s); err == nil {
+			s = v
+		}
+	}
+	id, err := ids.FromString(s)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("invalid ID or unknown name: %s", s)
+	}
+	return id, nil
+}
+
+func resetSubnetOpts(cmd *cobra.Command) {
+	subnetOpts.node = ""
+	subnetOpts.username = ""
+	subnetOpts.password = ""
+	subnetOpts.name = ""
+	subnetOpts.controlKeys = ""
+	subnetOpts.threshold = 1
+	subnetOpts.weight = 20
+	subnetOpts.start = 30 * time.Second
+	subnetOpts.duration = 24 * time.Hour
+	subnetOpts.vmID = ""
+	subnetOpts.fxIDs = ""
+	subnetOpts.genesis = ""
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetSubnetOpts(SubnetCmd)
+	SubnetCmd.PersistentFlags().StringVar(&subnetOpts.node, "node", subnetOpts.node, "Name of the node to issue requests to, defaulting to the first running node.")
+	SubnetCmd.PersistentFlags().StringVar(&subnetOpts.username, "user", subnetOpts.username, "Keystore user paying for transactions.")
+	SubnetCmd.PersistentFlags().StringVar(&subnetOpts.password, "password", subnetOpts.password, "Password of the keystore user.")
+
+	SubnetCreateCmd.Flags().StringVar(&subnetOpts.controlKeys, "control-keys", subnetOpts.controlKeys, "Comma separated P-chain addresses controlling the subnet.")
+	SubnetCreateCmd.Flags().Uint32Var(&subnetOpts.threshold, "threshold", subnetOpts.threshold, "Number of control key signatures required to add validators.")
+	SubnetCreateCmd.Flags().StringVar(&subnetOpts.name, "name", subnetOpts.name, "Name to record the subnet ID under, defaulting to the ID itself.")
+
+	SubnetAddValidatorCmd.Flags().Uint64Var(&subnetOpts.weight, "weight", subnetOpts.weight, "Weight of the validator when sampled.")
+	SubnetAddValidatorCmd.Flags().DurationVar(&subnetOpts.start, "start", subnetOpts.start, "Delay from now until the validation period starts.")
+	SubnetAddValidatorCmd.Flags().DurationVar(&subnetOpts.duration, "duration", subnetOpts.duration, "Length of the validation period.")
+
+	SubnetCreateChainCmd.Flags().StringVar(&subnetOpts.vmID, "vm", subnetOpts.vmID, "ID or alias of the VM the blockchain runs.")
+	SubnetCreateChainCmd.Flags().StringVar(&subnetOpts.fxIDs, "fx", subnetOpts.fxIDs, "Comma separated IDs of the feature extensions the VM runs.")
+	SubnetCreateChainCmd.Flags().StringVar(&subnetOpts.genesis, "genesis", subnetOpts.genesis, "Path to the file of genesis bytes of the blockchain.")
+	SubnetCreateChainCmd.Flags().StringVar(&subnetOpts.name, "name", subnetOpts.name, "Name of the blockchain, also used to record its ID. Defaults to the VM ID.")
+
+	SubnetCmd.AddCommand(SubnetAddValidatorCmd)
+	SubnetCmd.AddCommand(SubnetCreateChainCmd)
+	SubnetCmd.AddCommand(SubnetCreateCmd)
+	SubnetCmd.AddCommand(SubnetStatusCmd)
+}
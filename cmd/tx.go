@@ -0,0 +1,44 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// TxCmd represents the tx command
+var TxCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Tools for following X-chain transactions.",
+	Long:  `Tools for following X-chain transactions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// TxWaitCmd waits for a transaction to be decided
+var TxWaitCmd = &cobra.Command{
+	Use:   "wait [node name] [tx id]",
+	Short: "Waits for a transaction to be accepted or rejected.",
+	Long: `Polls a node until an X-chain transaction is accepted or rejected, or until
+	--timeout passes. The transaction is then fetched and decoded to work out its
+	fee, and a receipt holding its status, latency and fee is saved as JSON to
+	"receipts".[tx id]. The latency is measured from when the command started.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetWaitOpts(cmd)
+		if len(args) < 2 {
+			cmd.Help()
+			return
+		}
+		reportReceipt(args[0], args[1], time.Now(), waitOpts.timeout)
+	},
+}
+
+func init() {
+	resetWaitOpts(TxWaitCmd)
+	TxWaitCmd.Flags().DurationVar(&waitOpts.timeout, "timeout", waitOpts.timeout, "How long to wait for the transaction to be decided.")
+	TxCmd.AddCommand(TxWaitCmd)
+}
@@ -0,0 +1,240 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/node"
+	pmgr "github.com/ava-labs/avash/processmgr"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var validatorOpts struct {
+	from          string
+	password      string
+	stake         uint64
+	duration      time.Duration
+	start         time.Duration
+	delegationFee float64
+	rewardAddress string
+	timeout       time.Duration
+}
+
+// ValidatorCmd represents the validator command
+var ValidatorCmd = &cobra.Command{
+	Use:   "validator",
+	Short: "Tools for managing the primary network validators of local nodes.",
+	Long: `Tools for managing the primary network validators of local nodes. Using
+	this command you can register a node as a validator and list the current and
+	pending validators.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// ValidatorAddCmd registers a node as a primary network validator
+var ValidatorAddCmd = &cobra.Command{
+	Use:   "add [node name] --from [wallet, key or user]",
+	Short: "Registers a node as a primary network validator.",
+	Long: `Registers a node as a primary network validator, staking from the P-chain
+	funds given by --from and waiting for the transaction to be committed. --from
+	takes the name of an avaxwallet wallet or a private key, whose keys are
+	imported into an avash keystore user on the node, or the name of a keystore
+	user along with --password.
+
+	The stake and duration default to the node's --min-validator-stake and
+	--min-stake-duration.`,
+	Example: `validator add n2 --from PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN --duration 24h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer resetValidatorOpts(cmd)
+		if len(args) < 1 || validatorOpts.from == "" {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name := args[0]
+		id, err := nodeID(name)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		user, fromAddrs, err := spender(name, validatorOpts.from, validatorOpts.password)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		rewardAddress := validatorOpts.rewardAddress
+		if rewardAddress == "" {
+			if rewardAddress, err = firstAddress(name, user, fromAddrs); err != nil {
+				log.Error(err.Error())
+				return
+			}
+		}
+		nodeFlags, err := pmgr.ProcManager.NodeFlags(name)
+		if err != nil {
+			nodeFlags = node.DefaultFlags()
+		}
+		stake := validatorOpts.stake
+		if stake == 0 {
+			stake = uint64(nodeFlags.MinValidatorStake)
+		}
+		duration := validatorOpts.duration
+		if duration == 0 {
+			if duration, err = time.ParseDuration(nodeFlags.MinStakeDuration); err != nil {
+				log.Error("invalid min-stake-duration of %s: %s", name, err.Error())
+				return
+			}
+		}
+		start := time.Now().Add(validatorOpts.start)
+		stakeAmount := json.Uint64(stake)
+		var reply api.JSONTxIDChangeAddr
+		err = apiClient.Call(name, "ext/P", "platform.addValidator", platformvm.AddValidatorArgs{
+			JSONSpendHeader: api.JSONSpendHeader{
+				UserPass:      user,
+				JSONFromAddrs: api.JSONFromAddrs{From: fromAddrs},
+			},
+			APIStaker: platformvm.APIStaker{
+				NodeID:      id,
+				StartTime:   json.Uint64(start.Unix()),
+				EndTime:     json.Uint64(start.Add(duration).Unix()),
+				StakeAmount: &stakeAmount,
+			},
+			RewardAddress:     rewardAddress,
+			DelegationFeeRate: json.Float32(validatorOpts.delegationFee),
+		}, &reply)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("TxID:%s", reply.TxID)
+		if err := waitForPlatformTx(name, reply.TxID, validatorOpts.timeout); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("%s (%s) validates from %s until %s", name, id, start.Format(time.RFC3339), start.Add(duration).Format(time.RFC3339))
+	},
+}
+
+// ValidatorListCmd lists the primary network validators
+var ValidatorListCmd = &cobra.Command{
+	Use:   "list [node name]",
+	Short: "Lists the current and pending primary network validators.",
+	Long: `Lists the current and pending primary network validators known to a node,
+	mapping the node IDs of managed processes back to their names.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			return
+		}
+		log := cfg.Config.Log
+		name := args[0]
+		var current, pending struct {
+			Validators []platformvm.APIPrimaryValidator `json:"validators"`
+		}
+		if err := apiClient.Call(name, "ext/P", "platform.getCurrentValidators", struct{}{}, &current); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := apiClient.Call(name, "ext/P", "platform.getPendingValidators", struct{}{}, &pending); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		names := processNodeIDs()
+		table := tablewriter.NewWriter(AvalancheShell.rl.Stdout())
+		table.SetHeader([]string{"Node ID", "Process", "Status", "Stake", "Start", "End", "Uptime", "Connected"})
+		table.SetBorder(false)
+		for _, set := range []struct {
+			status     string
+			validators []platformvm.APIPrimaryValidator
+		}{{"current", current.Validators}, {"pending", pending.Validators}} {
+			for _, v := range set.validators {
+				stake, uptime, connected := "", "", ""
+				if v.StakeAmount != nil {
+					stake = fmt.Sprint(uint64(*v.StakeAmount))
+				}
+				if v.Uptime != nil {
+					uptime = fmt.Sprintf("%.2f%%", float32(*v.Uptime)*100)
+				}
+				if v.Connected != nil {
+					connected = fmt.Sprint(*v.Connected)
+				}
+				table.Append([]string{
+					v.NodeID,
+					names[v.NodeID],
+					set.status,
+					stake,
+					time.Unix(int64(v.StartTime), 0).Format(time.RFC3339),
+					time.Unix(int64(v.EndTime), 0).Format(time.RFC3339),
+					uptime,
+					connected,
+				})
+			}
+		}
+		table.Render()
+	},
+}
+
+// firstAddress returns the first of `addrs`, or else the first P-chain
+// address controlled by `user` on the named node
+func firstAddress(name string, user api.UserPass, addrs []string) (string, error) {
+	if len(addrs) > 0 {
+		return addrs[0], nil
+	}
+	var reply api.JSONAddresses
+	if err := apiClient.Call(name, "ext/P", "platform.listAddresses", user, &reply); err != nil {
+		return "", err
+	}
+	if len(reply.Addresses) == 0 {
+		return "", fmt.Errorf("keystore user %s has no P-chain addresses", user.Username)
+	}
+	return reply.Addresses[0], nil
+}
+
+// processNodeIDs maps the node IDs of running processes to their names
+func processNodeIDs() map[string]string {
+	names := make(map[string]string)
+	for _, name := range pmgr.ProcManager.Names() {
+		if running, err := pmgr.ProcManager.IsRunning(name); err != nil || !running {
+			continue
+		}
+		if id, err := nodeID(name); err == nil {
+			names[id] = name
+		}
+	}
+	return names
+}
+
+func resetValidatorOpts(cmd *cobra.Command) {
+	validatorOpts.from = ""
+	validatorOpts.password = ""
+	validatorOpts.stake = 0
+	validatorOpts.duration = 0
+	validatorOpts.start = 30 * time.Second
+	validatorOpts.delegationFee = 2
+	validatorOpts.rewardAddress = ""
+	validatorOpts.timeout = defaultTxTimeout
+	resetChangedFlags(cmd)
+}
+
+func init() {
+	resetValidatorOpts(ValidatorAddCmd)
+	ValidatorAddCmd.Flags().StringVar(&validatorOpts.from, "from", validatorOpts.from, "Wallet, private key or keystore user to stake from.")
+	ValidatorAddCmd.Flags().StringVar(&validatorOpts.password, "password", validatorOpts.password, "Password of the keystore user given by --from.")
+	ValidatorAddCmd.Flags().Uint64Var(&validatorOpts.stake, "stake", validatorOpts.stake, "Amount to stake in nAVAX, defaulting to the node's --min-validator-stake.")
+	ValidatorAddCmd.Flags().DurationVar(&validatorOpts.duration, "duration", validatorOpts.duration, "Length of the validation period, defaulting to the node's --min-stake-duration.")
+	ValidatorAddCmd.Flags().DurationVar(&validatorOpts.start, "start", validatorOpts.start, "Delay from now until the validation period starts.")
+	ValidatorAddCmd.Flags().Float64Var(&validatorOpts.delegationFee, "delegation-fee", validatorOpts.delegationFee, "Percent fee charged to delegators.")
+	ValidatorAddCmd.Flags().StringVar(&validatorOpts.rewardAddress, "reward-address", validatorOpts.rewardAddress, "P-chain address receiving the staking reward, defaulting to the first address of --from.")
+	ValidatorAddCmd.Flags().DurationVar(&validatorOpts.timeout, "timeout", validatorOpts.timeout, "How long to wait for the transaction to be committed.")
+
+	ValidatorCmd.AddCommand(ValidatorAddCmd)
+	ValidatorCmd.AddCommand(ValidatorListCmd)
+}
@@ -6,6 +6,7 @@ require (
 	github.com/AlecAivazis/survey/v2 v2.2.9
 	github.com/ava-labs/avalanchego v1.7.1
 	github.com/chzyer/readline v0.0.0-20180603132655-2972be24d48e
+	github.com/ethereum/go-ethereum v1.10.13
 	github.com/gorilla/mux v1.8.0
 	github.com/gorilla/rpc v1.2.0
 	github.com/kennygrant/sanitize v1.2.4
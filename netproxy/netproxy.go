@@ -0,0 +1,409 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+// Package netproxy forwards the connections between local nodes through
+// userspace TCP proxies, which can delay them, emulate loss on them and cut
+// them off to partition the nodes.
+package netproxy
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// RetransmitDelay is how much later a dropped chunk is delivered. A
+	// stream proxy can't lose bytes without breaking the stream, so a drop is
+	// emulated the way TCP recovers from one, by retransmitting after the
+	// minimum retransmission timeout.
+	RetransmitDelay = 200 * time.Millisecond
+
+	// dialTimeout bounds connecting to the node behind a proxy
+	dialTimeout = 5 * time.Second
+
+	// chunkSize is the most bytes forwarded at once
+	chunkSize = 32 * 1024
+
+	// chunkBacklog is how many chunks may be held back by a delay
+	chunkBacklog = 1024
+)
+
+// Rules are the faults injected into a link
+type Rules struct {
+	// Delay is added to everything sent over the link
+	Delay time.Duration
+	// Drop is the fraction, between 0 and 1, of chunks which are dropped and
+	// retransmitted
+	Drop float64
+}
+
+// Link is the state of the proxied connections between two nodes
+type Link struct {
+	A, B    string
+	Rules   Rules
+	Blocked bool
+	// Conns is the number of open connections between the nodes
+	Conns int
+}
+
+// Manager runs the proxies between nodes and the faults injected into them
+type Manager struct {
+	host   string
+	source *net.TCPAddr
+
+	lock    sync.Mutex
+	proxies map[[2]string]*proxy
+	rules   map[[2]string]Rules
+	// groups maps nodes to the partition they're in, if any
+	groups map[string]int
+}
+
+// NewManager returns a Manager whose proxies listen on `host` and, if `source`
+// isn't empty, connect to nodes from that IP
+func NewManager(host string, source string) *Manager {
+	m := &Manager{
+		host:    host,
+		proxies: make(map[[2]string]*proxy),
+		rules:   make(map[[2]string]Rules),
+		groups:  make(map[string]int),
+	}
+	if source != "" {
+		m.source = &net.TCPAddr{IP: net.ParseIP(source)}
+	}
+	return m
+}
+
+// Proxy returns the address of the proxy carrying the connections node `from`
+// makes to node `to`, which listens at `target`, starting the proxy if needed
+func (m *Manager) Proxy(from string, to string, target string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := [2]string{from, to}
+	if p, ok := m.proxies[key]; ok {
+		if p.target != target {
+			return "", fmt.Errorf("proxy from %s to %s already forwards to %s", from, to, p.target)
+		}
+		return p.listener.Addr().String(), nil
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(m.host, "0"))
+	if err != nil {
+		return "", err
+	}
+	p := &proxy{
+		manager:  m,
+		from:     from,
+		to:       to,
+		target:   target,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	m.proxies[key] = p
+	go p.serve()
+	return listener.Addr().String(), nil
+}
+
+// Proxied returns whether there's a proxy from or to the named node
+func (m *Manager) Proxied(name string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.proxies {
+		if key[0] == name || key[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Partition cuts off the nodes in each of `groups` from the nodes in the
+// others, replacing the previous partition. Nodes in no group stay connected
+// to every node.
+func (m *Manager) Partition(groups [][]string) error {
+	assigned := make(map[string]int)
+	for i, group := range groups {
+		for _, name := range group {
+			if j, ok := assigned[name]; ok && j != i {
+				return fmt.Errorf("%s is in more than one partition", name)
+			}
+			assigned[name] = i
+		}
+	}
+	m.lock.Lock()
+	m.groups = assigned
+	m.lock.Unlock()
+	m.cutBlocked()
+	return nil
+}
+
+// Heal removes the partition
+func (m *Manager) Heal() {
+	m.lock.Lock()
+	m.groups = make(map[string]int)
+	m.lock.Unlock()
+}
+
+// SetRules sets the faults injected into the link between nodes `a` and `b`,
+// in both directions
+func (m *Manager) SetRules(a string, b string, rules Rules) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if rules == (Rules{}) {
+		delete(m.rules, linkKey(a, b))
+		return
+	}
+	m.rules[linkKey(a, b)] = rules
+}
+
+// Rules returns the faults injected into the link between nodes `a` and `b`
+func (m *Manager) Rules(a string, b string) Rules {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.rules[linkKey(a, b)]
+}
+
+// Blocked returns whether nodes `a` and `b` are in different partitions
+func (m *Manager) Blocked(a string, b string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.blocked(a, b)
+}
+
+// Links returns the state of each link with a proxy or rules, sorted by node
+func (m *Manager) Links() []Link {
+	m.lock.Lock()
+	links := make(map[[2]string]*Link)
+	link := func(key [2]string) *Link {
+		if l, ok := links[key]; ok {
+			return l
+		}
+		l := &Link{A: key[0], B: key[1], Rules: m.rules[key], Blocked: m.blocked(key[0], key[1])}
+		links[key] = l
+		return l
+	}
+	for key := range m.rules {
+		link(key)
+	}
+	var proxies []*proxy
+	for _, p := range m.proxies {
+		proxies = append(proxies, p)
+	}
+	m.lock.Unlock()
+	for _, p := range proxies {
+		link(linkKey(p.from, p.to)).Conns += p.numConns()
+	}
+
+	var result []Link
+	for _, l := range links {
+		result = append(result, *l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].A != result[j].A {
+			return result[i].A < result[j].A
+		}
+		return result[i].B < result[j].B
+	})
+	return result
+}
+
+// Remove stops the proxies from and to the named node, closing their
+// connections, and forgets the faults injected into its links and its
+// partition
+func (m *Manager) Remove(name string) {
+	m.lock.Lock()
+	var removed []*proxy
+	for key, p := range m.proxies {
+		if key[0] == name || key[1] == name {
+			removed = append(removed, p)
+			delete(m.proxies, key)
+		}
+	}
+	for key := range m.rules {
+		if key[0] == name || key[1] == name {
+			delete(m.rules, key)
+		}
+	}
+	delete(m.groups, name)
+	m.lock.Unlock()
+	for _, p := range removed {
+		p.listener.Close()
+		p.cut()
+	}
+}
+
+// Close stops every proxy and closes their connections
+func (m *Manager) Close() {
+	m.lock.Lock()
+	proxies := m.proxies
+	m.proxies = make(map[[2]string]*proxy)
+	m.lock.Unlock()
+	for _, p := range proxies {
+		p.listener.Close()
+		p.cut()
+	}
+}
+
+// blocked assumes m.lock is held
+func (m *Manager) blocked(a string, b string) bool {
+	i, ok := m.groups[a]
+	j, ok2 := m.groups[b]
+	return ok && ok2 && i != j
+}
+
+// cutBlocked closes the connections between nodes in different partitions
+func (m *Manager) cutBlocked() {
+	m.lock.Lock()
+	var blocked []*proxy
+	for _, p := range m.proxies {
+		if m.blocked(p.from, p.to) {
+			blocked = append(blocked, p)
+		}
+	}
+	m.lock.Unlock()
+	for _, p := range blocked {
+		p.cut()
+	}
+}
+
+// linkKey returns the key of the link between nodes `a` and `b`, which doesn't
+// depend on their order
+func linkKey(a string, b string) [2]string {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// proxy forwards the connections one node makes to another
+type proxy struct {
+	manager  *Manager
+	from, to string
+	target   string
+	listener net.Listener
+
+	lock  sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func (p *proxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(conn)
+	}
+}
+
+// handle forwards `client` to the target of the proxy until either end closes
+func (p *proxy) handle(client net.Conn) {
+	if p.manager.Blocked(p.from, p.to) {
+		client.Close()
+		return
+	}
+	dialer := net.Dialer{Timeout: dialTimeout}
+	if p.manager.source != nil {
+		dialer.LocalAddr = p.manager.source
+	}
+	server, err := dialer.Dial("tcp", p.target)
+	if err != nil {
+		client.Close()
+		return
+	}
+	p.track(client, server)
+	defer p.untrack(client, server)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.forward(server, client)
+	}()
+	go func() {
+		defer wg.Done()
+		p.forward(client, server)
+	}()
+	wg.Wait()
+}
+
+// chunk is data read from one end of a connection and when to write it to the
+// other
+type chunk struct {
+	data []byte
+	due  time.Time
+}
+
+// forward copies `src` to `dst` with the faults of the link, then closes both
+func (p *proxy) forward(dst net.Conn, src net.Conn) {
+	defer dst.Close()
+	defer src.Close()
+	chunks := make(chan chunk, chunkBacklog)
+	go func() {
+		defer close(chunks)
+		var last time.Time
+		for {
+			buf := make([]byte, chunkSize)
+			n, err := src.Read(buf)
+			if n > 0 {
+				rules := p.manager.Rules(p.from, p.to)
+				due := time.Now().Add(rules.Delay)
+				if rules.Drop > 0 && rand.Float64() < rules.Drop {
+					due = due.Add(RetransmitDelay)
+				}
+				// A stream is delivered in order, so a held back chunk holds
+				// back every chunk after it
+				if due.Before(last) {
+					due = last
+				}
+				last = due
+				chunks <- chunk{data: buf[:n], due: due}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for c := range chunks {
+		time.Sleep(time.Until(c.due))
+		if _, err := dst.Write(c.data); err != nil {
+			break
+		}
+	}
+	src.Close()
+	for range chunks {
+	}
+}
+
+func (p *proxy) track(conns ...net.Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, c := range conns {
+		p.conns[c] = struct{}{}
+	}
+}
+
+func (p *proxy) untrack(conns ...net.Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, c := range conns {
+		delete(p.conns, c)
+	}
+}
+
+// numConns returns the number of connections the proxy is forwarding
+func (p *proxy) numConns() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.conns) / 2
+}
+
+// cut closes the connections the proxy is forwarding
+func (p *proxy) cut() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for c := range p.conns {
+		c.Close()
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package netproxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// echoServer returns the address of a server echoing every line it reads
+func echoServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+// roundTrip sends a line over `conn` and returns how long its echo took
+func roundTrip(t *testing.T, conn net.Conn) (time.Duration, error) {
+	start := time.Now()
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		return 0, err
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	assert.Equal(t, "ping\n", line)
+	return time.Since(start), nil
+}
+
+func TestProxyDelay(t *testing.T) {
+	m := NewManager("127.0.0.1", "")
+	defer m.Close()
+	addr, err := m.Proxy("n1", "n2", echoServer(t))
+	assert.NoError(t, err)
+	again, err := m.Proxy("n1", "n2", "127.0.0.1:1")
+	assert.Error(t, err)
+	assert.Empty(t, again)
+
+	conn, err := net.Dial("tcp", addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = roundTrip(t, conn)
+	assert.NoError(t, err)
+
+	// The delay applies in both directions of the link
+	m.SetRules("n2", "n1", Rules{Delay: 50 * time.Millisecond})
+	assert.Equal(t, Rules{Delay: 50 * time.Millisecond}, m.Rules("n1", "n2"))
+	rtt, err := roundTrip(t, conn)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(rtt), int64(100*time.Millisecond))
+
+	m.SetRules("n1", "n2", Rules{Drop: 1})
+	rtt, err = roundTrip(t, conn)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(rtt), int64(2*RetransmitDelay))
+
+	m.SetRules("n1", "n2", Rules{})
+	links := m.Links()
+	assert.Equal(t, []Link{{A: "n1", B: "n2", Conns: 1}}, links)
+}
+
+func TestPartition(t *testing.T) {
+	m := NewManager("127.0.0.1", "")
+	defer m.Close()
+	target := echoServer(t)
+	n1n2, err := m.Proxy("n1", "n2", target)
+	assert.NoError(t, err)
+	n1n3, err := m.Proxy("n1", "n3", target)
+	assert.NoError(t, err)
+
+	toN2, err := net.Dial("tcp", n1n2)
+	assert.NoError(t, err)
+	defer toN2.Close()
+	toN3, err := net.Dial("tcp", n1n3)
+	assert.NoError(t, err)
+	defer toN3.Close()
+	_, err = roundTrip(t, toN2)
+	assert.NoError(t, err)
+	_, err = roundTrip(t, toN3)
+	assert.NoError(t, err)
+
+	assert.Error(t, m.Partition([][]string{{"n1", "n2"}, {"n1"}}))
+	assert.NoError(t, m.Partition([][]string{{"n1", "n2"}, {"n3"}}))
+	assert.True(t, m.Blocked("n3", "n1"))
+	assert.False(t, m.Blocked("n1", "n2"))
+	assert.False(t, m.Blocked("n1", "n4"))
+
+	// Open connections across the partition are cut and new ones refused
+	_, err = roundTrip(t, toN2)
+	assert.NoError(t, err)
+	_, err = roundTrip(t, toN3)
+	assert.Error(t, err)
+	conn, err := net.Dial("tcp", n1n3)
+	assert.NoError(t, err)
+	_, err = roundTrip(t, conn)
+	assert.Error(t, err)
+	conn.Close()
+
+	m.Heal()
+	assert.False(t, m.Blocked("n1", "n3"))
+	conn, err = net.Dial("tcp", n1n3)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = roundTrip(t, conn)
+	assert.NoError(t, err)
+}
+
+func TestRemove(t *testing.T) {
+	m := NewManager("127.0.0.1", "")
+	defer m.Close()
+	addr, err := m.Proxy("n1", "n2", echoServer(t))
+	assert.NoError(t, err)
+	conn, err := net.Dial("tcp", addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = roundTrip(t, conn)
+	assert.NoError(t, err)
+	m.SetRules("n1", "n2", Rules{Delay: time.Millisecond})
+
+	m.Remove("n2")
+	assert.False(t, m.Proxied("n2"))
+	assert.Empty(t, m.Links())
+	_, err = roundTrip(t, conn)
+	assert.Error(t, err)
+
+	// The node can come back at another address
+	_, err = m.Proxy("n1", "n2", echoServer(t))
+	assert.NoError(t, err)
+}
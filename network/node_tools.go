@@ -128,6 +128,9 @@ func Deploy(deploys []DeployConfig, isPrompt bool) error {
 					log.Error("%s: %s", ip, err.Error())
 					return
 				}
+				for _, f := range node.UnknownFlags(node.ExtraArgs(n.Flags)) {
+					log.Warn("%s: forwarding unrecognized flag to %s: --%s", ip, n.Name, f)
+				}
 				basename := sanitize.BaseName(n.Name)
 				datapath := datadir + "/" + basename
 				flags, _ := node.FlagsToArgs(n.Flags, datapath, true)
@@ -88,6 +88,7 @@ func validateConfig(cfg RawConfig, cfgpath string) error {
 		return fmt.Errorf("%s: config must contain at least one node definition", cfgpath)
 	}
 	isNode := make(map[string]bool)
+	classFlags := make(map[string]node.FlagsYAML)
 	for _, n := range cfg.Nodes {
 		if n.Class == "" {
 			return fmt.Errorf("%s: node definition missing class name", cfgpath)
@@ -96,6 +97,7 @@ func validateConfig(cfg RawConfig, cfgpath string) error {
 			return fmt.Errorf("%s: duplicate node class name: %s", cfgpath, n.Class)
 		}
 		isNode[n.Class] = true
+		classFlags[n.Class] = n.Flags
 	}
 	if len(cfg.Deploys) == 0 {
 		return fmt.Errorf("%s: config must contain at least one deploy", cfgpath)
@@ -116,6 +118,7 @@ func validateConfig(cfg RawConfig, cfgpath string) error {
 		}
 		isDeployHost[deploy.Host] = true
 		isDeployNode := make(map[string]bool)
+		portUsedBy := make(map[uint]string)
 		for _, n := range deploy.Nodes {
 			if n.Name == "" {
 				return fmt.Errorf("%s: deploy node missing name", cfgpath)
@@ -129,6 +132,18 @@ func validateConfig(cfg RawConfig, cfgpath string) error {
 			if !isNode[n.Class] {
 				return fmt.Errorf("%s: deploy node with undefined class name: %s", cfgpath, n.Class)
 			}
+			flags := classFlags[n.Class]
+			overrideFlags(&flags, n.Flags)
+			nodeFlags := node.ConvertYAML(flags)
+			if err := node.Validate(nodeFlags); err != nil {
+				return fmt.Errorf("%s: deploy node '%s' on host '%s': %s", cfgpath, n.Name, deploy.Host, err.Error())
+			}
+			for _, port := range []uint{nodeFlags.HTTPPort, nodeFlags.StakingPort} {
+				if other, ok := portUsedBy[port]; ok {
+					return fmt.Errorf("%s: deploy nodes '%s' and '%s' on host '%s' share port %d", cfgpath, other, n.Name, deploy.Host, port)
+				}
+				portUsedBy[port] = n.Name
+			}
 			isDeployNode[n.Name] = true
 		}
 	}
@@ -116,9 +116,9 @@ do
             ;;
         *)
             echo
-            echo "ERROR: Unsupported flag '${arg%%=*}'"
+            echo "WARNING: Unrecognized flag '${arg%%=*}', forwarding as-is"
             echo
-            exit 1
+            FLAGS+="${arg} "
             ;;
     esac
 done
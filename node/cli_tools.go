@@ -3,12 +3,33 @@ package node
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// flagValue is an avalanchego flag name paired with its typed value
+type flagValue struct {
+	name  string
+	value interface{}
+}
+
 // FlagsToArgs converts a `Flags` struct into a CLI command flag string
 func FlagsToArgs(flags Flags, basedir string, sepBase bool) ([]string, Metadata) {
+	values, metadata := flagValues(flags, basedir, sepBase)
+	var args []string
+	for _, v := range values {
+		args = append(args, "--"+v.name+"="+formatValue(v.value))
+	}
+	args = removeEmptyFlags(args)
+	args = append(args, ExtraArgs(flags)...)
+	return args, metadata
+}
+
+// flagValues converts a `Flags` struct into its avalanchego flag values and
+// the metadata of the resulting node
+func flagValues(flags Flags, basedir string, sepBase bool) ([]flagValue, Metadata) {
 	// Port targets
 	httpPortString := strconv.FormatUint(uint64(flags.HTTPPort), 10)
 	stakingPortString := strconv.FormatUint(uint64(flags.StakingPort), 10)
@@ -47,102 +68,102 @@ func FlagsToArgs(flags Flags, basedir string, sepBase bool) ([]string, Metadata)
 		stakerKeyFile = fmt.Sprintf("%s/%s", wd, stakerKeyFile)
 	}
 
-	args := []string{
-		"--assertions-enabled=" + strconv.FormatBool(flags.AssertionsEnabled),
-		"--version=" + strconv.FormatBool(flags.Version),
-		"--tx-fee=" + strconv.FormatUint(uint64(flags.TxFee), 10),
-		"--public-ip=" + flags.PublicIP,
-		"--dynamic-update-duration=" + flags.DynamicUpdateDuration,
-		"--dynamic-public-ip=" + flags.DynamicPublicIP,
-		"--network-id=" + flags.NetworkID,
-		"--signature-verification-enabled=" + strconv.FormatBool(flags.SignatureVerificationEnabled),
-		"--api-admin-enabled=" + strconv.FormatBool(flags.APIAdminEnabled),
-		"--api-ipcs-enabled=" + strconv.FormatBool(flags.APIIPCsEnabled),
-		"--api-keystore-enabled=" + strconv.FormatBool(flags.APIKeystoreEnabled),
-		"--api-metrics-enabled=" + strconv.FormatBool(flags.APIMetricsEnabled),
-		"--http-host=" + flags.HTTPHost,
-		"--http-port=" + httpPortString,
-		"--http-tls-enabled=" + strconv.FormatBool(flags.HTTPTLSEnabled),
-		"--http-tls-cert-file=" + httpCertFile,
-		"--http-tls-key-file=" + httpKeyFile,
-		"--bootstrap-ips=" + flags.BootstrapIPs,
-		"--bootstrap-ids=" + flags.BootstrapIDs,
-		"--bootstrap-beacon-connection-timeout=" + flags.BootstrapBeaconConnectionTimeout,
-		"--db-type=" + dbType,
-		"--db-dir=" + dbPath,
-		"--plugin-dir=" + flags.PluginDir,
-		"--build-dir=" + flags.BuildDir,
-		"--log-level=" + flags.LogLevel,
-		"--log-dir=" + logPath,
-		"--log-display-level=" + flags.LogDisplayLevel,
-		"--log-display-highlight=" + flags.LogDisplayHighlight,
-		"--snow-avalanche-batch-size=" + strconv.Itoa(flags.SnowAvalancheBatchSize),
-		"--snow-avalanche-num-parents=" + strconv.Itoa(flags.SnowAvalancheNumParents),
-		"--snow-sample-size=" + strconv.Itoa(flags.SnowSampleSize),
-		"--snow-quorum-size=" + strconv.Itoa(flags.SnowQuorumSize),
-		"--snow-virtuous-commit-threshold=" + strconv.Itoa(flags.SnowVirtuousCommitThreshold),
-		"--min-delegator-stake=" + strconv.Itoa(flags.MinDelegatorStake),
-		"--consensus-shutdown-timeout=" + flags.ConsensusShutdownTimeout,
-		"--consensus-gossip-frequency=" + flags.ConsensusGossipFrequency,
-		"--min-delegation-fee=" + strconv.Itoa(flags.MinDelegationFee),
-		"--min-validator-stake=" + strconv.Itoa(flags.MinValidatorStake),
-		"--max-stake-duration=" + flags.MaxStakeDuration,
-		"--max-validator-stake=" + strconv.Itoa(flags.MaxValidatorStake),
-		"--snow-concurrent-repolls=" + strconv.Itoa(flags.SnowConcurrentRepolls),
-		"--stake-minting-period=" + flags.StakeMintingPeriod,
-		"--network-initial-timeout=" + flags.NetworkInitialTimeout,
-		"--network-minimum-timeout=" + flags.NetworkMinimumTimeout,
-		"--network-maximum-timeout=" + flags.NetworkMaximumTimeout,
-		fmt.Sprintf("--network-health-max-send-fail-rate=%f", flags.NetworkHealthMaxSendFailRateKey),
-		fmt.Sprintf("--network-health-max-portion-send-queue-full=%f", flags.NetworkHealthMaxPortionSendQueueFillKey),
-		"--network-health-max-time-since-msg-sent=" + flags.NetworkHealthMaxTimeSinceMsgSentKey,
-		"--network-health-max-time-since-msg-received=" + flags.NetworkHealthMaxTimeSinceMsgReceivedKey,
-		"--network-health-min-conn-peers=" + strconv.Itoa(flags.NetworkHealthMinConnPeers),
-		"--network-timeout-coefficient=" + strconv.Itoa(flags.NetworkTimeoutCoefficient),
-		"--network-timeout-halflife=" + flags.NetworkTimeoutHalflife,
-		"--network-peer-list-gossip-frequency=" + flags.NetworkPeerListGossipFrequency,
-		"--network-peer-list-gossip-size=" + strconv.Itoa(flags.NetworkPeerListGossipSize),
-		"--network-peer-list-size=" + strconv.Itoa(flags.NetworkPeerListSize),
-		"--staking-enabled=" + strconv.FormatBool(flags.StakingEnabled),
-		"--staking-port=" + stakingPortString,
-		"--staking-disabled-weight=" + strconv.Itoa(flags.StakingDisabledWeight),
-		"--staking-tls-key-file=" + stakerKeyFile,
-		"--staking-tls-cert-file=" + stakerCertFile,
-		"--api-auth-required=" + strconv.FormatBool(flags.APIAuthRequired),
-		"--api-auth-password-file=" + flags.APIAuthPasswordFileKey,
-		"--min-stake-duration=" + flags.MinStakeDuration,
-		"--whitelisted-subnets=" + flags.WhitelistedSubnets,
-		"--api-health-enabled=" + strconv.FormatBool(flags.APIHealthEnabled),
-		"--config-file=" + flags.ConfigFile,
-		"--api-info-enabled=" + strconv.FormatBool(flags.APIInfoEnabled),
-		"--network-compression-enabled=" + strconv.FormatBool(flags.NetworkCompressionEnabled),
-		"--ipcs-chain-ids=" + flags.IPCSChainIDs,
-		"--ipcs-path=" + flags.IPCSPath,
-		"--fd-limit=" + strconv.Itoa(flags.FDLimit),
-		"--benchlist-duration=" + flags.BenchlistDuration,
-		"--benchlist-fail-threshold=" + strconv.Itoa(flags.BenchlistFailThreshold),
-		"--benchlist-min-failing-duration=" + flags.BenchlistMinFailingDuration,
-		"--benchlist-peer-summary-enabled=" + strconv.FormatBool(flags.BenchlistPeerSummaryEnabled),
-		fmt.Sprintf("--uptime-requirement=%f", flags.UptimeRequirement),
-		"--bootstrap-retry-enabled=" + strconv.FormatBool(flags.RetryBootstrap),
-		"--health-check-averager-halflife=" + flags.HealthCheckAveragerHalflifeKey,
-		"--health-check-frequency=" + flags.HealthCheckFreqKey,
-		"--router-health-max-outstanding-requests=" + strconv.Itoa(flags.RouterHealthMaxOutstandingRequestsKey),
-		fmt.Sprintf("--router-health-max-drop-rate=%f", flags.RouterHealthMaxDropRateKey),
-		"--index-enabled=" + strconv.FormatBool(flags.IndexEnabled),
-		"--plugin-mode-enabled=" + strconv.FormatBool(flags.PluginModeEnabled),
-		"--meter-vms-enabled=" + strconv.FormatBool(flags.MeterVMsEnabled),
+	values := []flagValue{
+		{"assertions-enabled", flags.AssertionsEnabled},
+		{"version", flags.Version},
+		{"tx-fee", flags.TxFee},
+		{"public-ip", flags.PublicIP},
+		{"dynamic-update-duration", flags.DynamicUpdateDuration},
+		{"dynamic-public-ip", flags.DynamicPublicIP},
+		{"network-id", flags.NetworkID},
+		{"signature-verification-enabled", flags.SignatureVerificationEnabled},
+		{"api-admin-enabled", flags.APIAdminEnabled},
+		{"api-ipcs-enabled", flags.APIIPCsEnabled},
+		{"api-keystore-enabled", flags.APIKeystoreEnabled},
+		{"api-metrics-enabled", flags.APIMetricsEnabled},
+		{"http-host", flags.HTTPHost},
+		{"http-port", flags.HTTPPort},
+		{"http-tls-enabled", flags.HTTPTLSEnabled},
+		{"http-tls-cert-file", httpCertFile},
+		{"http-tls-key-file", httpKeyFile},
+		{"bootstrap-ips", flags.BootstrapIPs},
+		{"bootstrap-ids", flags.BootstrapIDs},
+		{"bootstrap-beacon-connection-timeout", flags.BootstrapBeaconConnectionTimeout},
+		{"db-type", dbType},
+		{"db-dir", dbPath},
+		{"plugin-dir", flags.PluginDir},
+		{"build-dir", flags.BuildDir},
+		{"log-level", flags.LogLevel},
+		{"log-dir", logPath},
+		{"log-display-level", flags.LogDisplayLevel},
+		{"log-display-highlight", flags.LogDisplayHighlight},
+		{"snow-avalanche-batch-size", flags.SnowAvalancheBatchSize},
+		{"snow-avalanche-num-parents", flags.SnowAvalancheNumParents},
+		{"snow-sample-size", flags.SnowSampleSize},
+		{"snow-quorum-size", flags.SnowQuorumSize},
+		{"snow-virtuous-commit-threshold", flags.SnowVirtuousCommitThreshold},
+		{"min-delegator-stake", flags.MinDelegatorStake},
+		{"consensus-shutdown-timeout", flags.ConsensusShutdownTimeout},
+		{"consensus-gossip-frequency", flags.ConsensusGossipFrequency},
+		{"min-delegation-fee", flags.MinDelegationFee},
+		{"min-validator-stake", flags.MinValidatorStake},
+		{"max-stake-duration", flags.MaxStakeDuration},
+		{"max-validator-stake", flags.MaxValidatorStake},
+		{"snow-concurrent-repolls", flags.SnowConcurrentRepolls},
+		{"stake-minting-period", flags.StakeMintingPeriod},
+		{"network-initial-timeout", flags.NetworkInitialTimeout},
+		{"network-minimum-timeout", flags.NetworkMinimumTimeout},
+		{"network-maximum-timeout", flags.NetworkMaximumTimeout},
+		{"network-health-max-send-fail-rate", flags.NetworkHealthMaxSendFailRateKey},
+		{"network-health-max-portion-send-queue-full", flags.NetworkHealthMaxPortionSendQueueFillKey},
+		{"network-health-max-time-since-msg-sent", flags.NetworkHealthMaxTimeSinceMsgSentKey},
+		{"network-health-max-time-since-msg-received", flags.NetworkHealthMaxTimeSinceMsgReceivedKey},
+		{"network-health-min-conn-peers", flags.NetworkHealthMinConnPeers},
+		{"network-timeout-coefficient", flags.NetworkTimeoutCoefficient},
+		{"network-timeout-halflife", flags.NetworkTimeoutHalflife},
+		{"network-peer-list-gossip-frequency", flags.NetworkPeerListGossipFrequency},
+		{"network-peer-list-gossip-size", flags.NetworkPeerListGossipSize},
+		{"network-peer-list-size", flags.NetworkPeerListSize},
+		{"staking-enabled", flags.StakingEnabled},
+		{"staking-port", flags.StakingPort},
+		{"staking-disabled-weight", flags.StakingDisabledWeight},
+		{"staking-tls-key-file", stakerKeyFile},
+		{"staking-tls-cert-file", stakerCertFile},
+		{"api-auth-required", flags.APIAuthRequired},
+		{"api-auth-password-file", flags.APIAuthPasswordFileKey},
+		{"min-stake-duration", flags.MinStakeDuration},
+		{"whitelisted-subnets", flags.WhitelistedSubnets},
+		{"api-health-enabled", flags.APIHealthEnabled},
+		{"config-file", flags.ConfigFile},
+		{"api-info-enabled", flags.APIInfoEnabled},
+		{"network-compression-enabled", flags.NetworkCompressionEnabled},
+		{"ipcs-chain-ids", flags.IPCSChainIDs},
+		{"ipcs-path", flags.IPCSPath},
+		{"fd-limit", flags.FDLimit},
+		{"benchlist-duration", flags.BenchlistDuration},
+		{"benchlist-fail-threshold", flags.BenchlistFailThreshold},
+		{"benchlist-min-failing-duration", flags.BenchlistMinFailingDuration},
+		{"benchlist-peer-summary-enabled", flags.BenchlistPeerSummaryEnabled},
+		{"uptime-requirement", flags.UptimeRequirement},
+		{"bootstrap-retry-enabled", flags.RetryBootstrap},
+		{"health-check-averager-halflife", flags.HealthCheckAveragerHalflifeKey},
+		{"health-check-frequency", flags.HealthCheckFreqKey},
+		{"router-health-max-outstanding-requests", flags.RouterHealthMaxOutstandingRequestsKey},
+		{"router-health-max-drop-rate", flags.RouterHealthMaxDropRateKey},
+		{"index-enabled", flags.IndexEnabled},
+		{"plugin-mode-enabled", flags.PluginModeEnabled},
+		{"meter-vms-enabled", flags.MeterVMsEnabled},
 	}
 	if sepBase {
-		args = append(args, "--data-dir="+basedir)
+		values = append(values, flagValue{"data-dir", basedir})
 	}
-	args = removeEmptyFlags(args)
 
 	metadata := Metadata{
 		Serverhost:     flags.PublicIP,
 		Stakingport:    stakingPortString,
 		HTTPport:       httpPortString,
 		HTTPTLS:        flags.HTTPTLSEnabled,
+		HTTPTLSCert:    httpCertFile,
 		Dbdir:          dbPath,
 		Datadir:        dataPath,
 		Logsdir:        logPath,
@@ -152,7 +173,22 @@ func FlagsToArgs(flags Flags, basedir string, sepBase bool) ([]string, Metadata)
 		StakerKeyPath:  stakerKeyFile,
 	}
 
-	return args, metadata
+	return values, metadata
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return fmt.Sprintf("%f", v)
+	default:
+		return fmt.Sprint(v)
+	}
 }
 
 func removeEmptyFlags(args []string) []string {
@@ -165,3 +201,52 @@ func removeEmptyFlags(args []string) []string {
 	}
 	return res
 }
+
+// ExtraArgs returns the passthrough flags of `flags` in CLI form: `ExtraFlags`
+// sorted by name, followed by the whitespace separated words of `ExtraArgs`
+func ExtraArgs(flags Flags) []string {
+	var names []string
+	for name := range flags.ExtraFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var args []string
+	for _, name := range names {
+		arg := "--" + strings.TrimLeft(name, "-")
+		if v := flags.ExtraFlags[name]; v != "" {
+			arg += "=" + v
+		}
+		args = append(args, arg)
+	}
+	return append(args, strings.Fields(flags.ExtraArgs)...)
+}
+
+// UnknownFlags returns the flag names in `args` which aren't modeled by Flags
+func UnknownFlags(args []string) []string {
+	known := knownFlags()
+	var res []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if !known[name] {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// knownFlags returns the set of avalanchego flag names modeled by Flags
+func knownFlags() map[string]bool {
+	known := map[string]bool{"data-dir": true}
+	t := reflect.TypeOf(FlagsYAML{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" || name == "extra-flags" {
+			continue
+		}
+		known[name] = true
+	}
+	return known
+}
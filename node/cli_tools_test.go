@@ -0,0 +1,41 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraArgs(t *testing.T) {
+	flags := DefaultFlags()
+	flags.ExtraFlags = map[string]string{
+		"snow-max-processing":         "1024",
+		"--network-allow-private-ips": "",
+	}
+	flags.ExtraArgs = "--foo=bar  --baz"
+
+	assert.Equal(t, []string{
+		"--network-allow-private-ips",
+		"--snow-max-processing=1024",
+		"--foo=bar",
+		"--baz",
+	}, ExtraArgs(flags))
+
+	args, _ := FlagsToArgs(flags, "/tmp/node", false)
+	assert.Equal(t, []string{"--foo=bar", "--baz"}, args[len(args)-2:])
+}
+
+func TestUnknownFlags(t *testing.T) {
+	args := []string{
+		"--http-port=9650",
+		"--data-dir=/tmp",
+		"--meter-vms-enabled",
+		"--foo=bar",
+		"value",
+		"-baz",
+	}
+	assert.Equal(t, []string{"foo", "baz"}, UnknownFlags(args))
+
+	known, _ := FlagsToArgs(DefaultFlags(), "/tmp/node", true)
+	assert.Empty(t, UnknownFlags(known))
+}
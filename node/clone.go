@@ -0,0 +1,62 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+
+	"github.com/ava-labs/avalanchego/staking"
+)
+
+// Clone returns a copy of `flags` for a new node whose data lives in `basedir`.
+// Its ports are re-allocated past those in `taken`, and a fresh staking key
+// pair is generated in `basedir` if the original node has one.
+func Clone(flags Flags, basedir string, taken map[uint]bool) (Flags, error) {
+	clone := flags
+	clone.Meta = ""
+	if flags.ExtraFlags != nil {
+		clone.ExtraFlags = make(map[string]string, len(flags.ExtraFlags))
+		for k, v := range flags.ExtraFlags {
+			clone.ExtraFlags[k] = v
+		}
+	}
+	AllocatePorts(&clone, taken)
+	if flags.StakingTLSCertFile != "" || flags.StakingTLSKeyFile != "" {
+		certPath := filepath.Join(basedir, "staking", "staker.crt")
+		keyPath := filepath.Join(basedir, "staking", "staker.key")
+		if err := staking.InitNodeStakingKeyPair(keyPath, certPath); err != nil {
+			return clone, fmt.Errorf("unable to create staking key pair: %s", err.Error())
+		}
+		clone.StakingTLSCertFile = certPath
+		clone.StakingTLSKeyFile = keyPath
+	}
+	return clone, nil
+}
+
+// AllocatePorts moves the HTTP and staking ports of `flags` to the first
+// available ports at or above their current values, skipping those in `taken`.
+// The allocated ports are added to `taken`.
+func AllocatePorts(flags *Flags, taken map[uint]bool) {
+	flags.HTTPPort = nextPort(flags.HTTPPort, taken)
+	taken[flags.HTTPPort] = true
+	flags.StakingPort = nextPort(flags.StakingPort, taken)
+	taken[flags.StakingPort] = true
+}
+
+func nextPort(port uint, taken map[uint]bool) uint {
+	for ; port < 65535; port++ {
+		if !taken[port] && portAvailable(port) {
+			break
+		}
+	}
+	return port
+}
+
+func portAvailable(port uint) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
@@ -0,0 +1,44 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avash-node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	flags := DefaultFlags()
+	flags.Meta = "custom"
+	flags.HTTPPort = 19658
+	flags.StakingPort = 19659
+	flags.StakingEnabled = true
+	flags.StakingTLSCertFile = "certs/keys5/staker.crt"
+	flags.StakingTLSKeyFile = "certs/keys5/staker.key"
+	flags.ExtraFlags = map[string]string{"snow-max-processing": "1024"}
+
+	taken := map[uint]bool{19658: true, 19659: true, 19660: true}
+	clone, err := Clone(flags, dir, taken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint(19661), clone.HTTPPort)
+	assert.Equal(t, uint(19662), clone.StakingPort)
+	assert.True(t, taken[19661] && taken[19662])
+	assert.Empty(t, clone.Meta)
+	assert.True(t, clone.StakingEnabled)
+	assert.Equal(t, filepath.Join(dir, "staking", "staker.crt"), clone.StakingTLSCertFile)
+	assert.FileExists(t, clone.StakingTLSCertFile)
+	assert.FileExists(t, clone.StakingTLSKeyFile)
+
+	clone.ExtraFlags["snow-max-processing"] = "1"
+	assert.Equal(t, "1024", flags.ExtraFlags["snow-max-processing"])
+}
@@ -0,0 +1,193 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is a semantic version of avalanchego
+type Version struct {
+	Major, Minor, Patch int
+}
+
+var versionRegexp = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// ParseVersion parses the first "major.minor.patch" version found in `s`,
+// e.g. the output of `avalanchego --version`: "avalanche/1.7.1 [...]"
+func ParseVersion(s string) (Version, error) {
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("no version found in: %q", strings.TrimSpace(s))
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return Version{major, minor, patch}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// IsZero returns true if the version is unset
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// Less returns true if `v` is an earlier version than `o`
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// FlagCompat describes the avalanchego versions which accept a flag
+// `Since` is the first version accepting the flag, and `Until` the first which
+// no longer does. Before `Since`, the flag is translated to `OldName` if set.
+type FlagCompat struct {
+	Since, Until Version
+	OldName      string
+}
+
+// CompatTable maps flag names emitted by avash to their compatibility
+var CompatTable = map[string]FlagCompat{
+	"plugin-dir":                     {Until: Version{1, 7, 0}},
+	"build-dir":                      {Since: Version{1, 4, 5}},
+	"bootstrap-retry-enabled":        {Since: Version{1, 4, 5}, OldName: "retry-bootstrap"},
+	"bootstrap-retry-warn-frequency": {Since: Version{1, 4, 5}, OldName: "retry-bootstrap-warn-frequency"},
+	"meter-vms-enabled":              {Since: Version{1, 4, 5}},
+	"plugin-mode-enabled":            {Since: Version{1, 4, 5}},
+	"network-compression-enabled":    {Since: Version{1, 4, 10}},
+	"index-enabled":                  {Since: Version{1, 3, 2}},
+	"dynamic-update-duration":        {Since: Version{1, 3, 0}},
+	"snow-epoch-duration":            {Until: Version{1, 4, 5}},
+	"snow-epoch-first-transition":    {Until: Version{1, 4, 5}},
+}
+
+// CompatFlag returns the name `flag` must be given for avalanchego `v`, and
+// false if `v` doesn't accept the flag at all
+func CompatFlag(flag string, v Version) (string, bool) {
+	c, ok := CompatTable[flag]
+	if !ok || v.IsZero() {
+		return flag, true
+	}
+	if !c.Until.IsZero() && !v.Less(c.Until) {
+		return "", false
+	}
+	if !c.Since.IsZero() && v.Less(c.Since) {
+		if c.OldName == "" {
+			return "", false
+		}
+		return c.OldName, true
+	}
+	return flag, true
+}
+
+// FilterArgs drops or translates the flags in `args` according to
+// `CompatTable`, returning the new args and a warning for each changed flag
+func FilterArgs(args []string, v Version) ([]string, []string) {
+	var filtered, warnings []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			filtered = append(filtered, arg)
+			continue
+		}
+		kv := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+		name, ok := CompatFlag(kv[0], v)
+		switch {
+		case !ok:
+			warnings = append(warnings, fmt.Sprintf("--%s is not supported by avalanchego %s, dropping it", kv[0], v))
+			continue
+		case name != kv[0]:
+			warnings = append(warnings, fmt.Sprintf("--%s is named --%s in avalanchego %s", kv[0], name, v))
+			kv[0] = name
+		}
+		filtered = append(filtered, "--"+strings.Join(kv, "="))
+	}
+	return filtered, warnings
+}
+
+// FilterConfigFile applies `CompatTable` to the keys of the JSON config file
+// at `fp` in place, returning a warning for each changed flag
+func FilterConfigFile(fp string, v Version) ([]string, error) {
+	config, err := readConfigFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	for key, value := range config {
+		name, ok := CompatFlag(key, v)
+		switch {
+		case !ok:
+			warnings = append(warnings, fmt.Sprintf("%s is not supported by avalanchego %s, dropping it", key, v))
+			delete(config, key)
+		case name != key:
+			warnings = append(warnings, fmt.Sprintf("%s is named %s in avalanchego %s", key, name, v))
+			delete(config, key)
+			config[name] = value
+		}
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+	configBytes, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return warnings, ioutil.WriteFile(fp, configBytes, 0644)
+}
+
+// versionTimeout is how long `avalanchego --version` may run for
+const versionTimeout = 10 * time.Second
+
+type cachedVersion struct {
+	modTime time.Time
+	version Version
+	err     error
+}
+
+var (
+	versionCache   = make(map[string]cachedVersion)
+	versionCacheMu sync.Mutex
+)
+
+// DetectVersion returns the version reported by `binary --version`
+// Results, including failures, are cached until the binary is modified.
+func DetectVersion(binary string) (Version, error) {
+	info, err := os.Stat(binary)
+	if err != nil {
+		return Version{}, err
+	}
+	versionCacheMu.Lock()
+	defer versionCacheMu.Unlock()
+	if c, ok := versionCache[binary]; ok && c.modTime.Equal(info.ModTime()) {
+		return c.version, c.err
+	}
+	v, err := runVersion(binary)
+	versionCache[binary] = cachedVersion{info.ModTime(), v, err}
+	return v, err
+}
+
+// runVersion runs `binary --version` and parses its output
+func runVersion(binary string) (Version, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, binary, "--version").Output()
+	if err != nil {
+		return Version{}, fmt.Errorf("unable to run %s --version: %s", binary, err.Error())
+	}
+	return ParseVersion(string(out))
+}
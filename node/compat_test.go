@@ -0,0 +1,76 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("avalanche/1.7.1 [database=v1.4.5, commit=f5f1b6e]")
+	assert.NoError(t, err)
+	assert.Equal(t, Version{1, 7, 1}, v)
+	assert.True(t, Version{1, 4, 10}.Less(v))
+	assert.False(t, v.Less(Version{1, 7, 1}))
+	_, err = ParseVersion("avalanche")
+	assert.Error(t, err)
+}
+
+func TestFilterArgs(t *testing.T) {
+	args := []string{"--plugin-dir=/plugins", "--bootstrap-retry-enabled=true", "--http-port=9650"}
+
+	filtered, warnings := FilterArgs(args, Version{1, 7, 1})
+	assert.Equal(t, []string{"--bootstrap-retry-enabled=true", "--http-port=9650"}, filtered)
+	assert.Len(t, warnings, 1)
+
+	filtered, warnings = FilterArgs(args, Version{1, 4, 4})
+	assert.Equal(t, []string{"--plugin-dir=/plugins", "--retry-bootstrap=true", "--http-port=9650"}, filtered)
+	assert.Len(t, warnings, 1)
+
+	filtered, warnings = FilterArgs(args, Version{})
+	assert.Equal(t, args, filtered)
+	assert.Empty(t, warnings)
+}
+
+func TestFilterConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avash-node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fp := filepath.Join(dir, ConfigFileName)
+	assert.NoError(t, ioutil.WriteFile(fp, []byte(`{"plugin-dir": "/plugins", "http-port": 9650}`), 0644))
+	warnings, err := FilterConfigFile(fp, Version{1, 7, 1})
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	config, err := readConfigFile(fp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"http-port": float64(9650)}, config)
+}
+
+func TestDetectVersionCachesFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avash-compat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The binary records each run and fails
+	runs := filepath.Join(dir, "runs")
+	binary := filepath.Join(dir, "avalanchego")
+	script := "#!/bin/sh\necho run >> " + runs + "\nexit 1\n"
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := DetectVersion(binary)
+		assert.Error(t, err)
+	}
+	out, err := ioutil.ReadFile(runs)
+	assert.NoError(t, err)
+	assert.Equal(t, "run\n", string(out))
+}
@@ -8,8 +8,12 @@ import (
 type Flags struct {
 	// Avash metadata
 	ClientLocation string
+	Binary         string
 	Meta           string
 	DataDir        string
+	UseConfigFile  bool
+	Group          string
+	Proxied        bool
 
 	// Assertions
 	AssertionsEnabled bool
@@ -153,18 +157,25 @@ type Flags struct {
 	PluginModeEnabled bool
 
 	MeterVMsEnabled bool
+
+	// Passthrough
+	ExtraArgs  string
+	ExtraFlags map[string]string
 }
 
 // FlagsYAML mimics Flags but uses pointers for proper YAML interpretation
-// Note: FlagsYAML and Flags must always be identical in fields, otherwise parsing will break
+// Note: every field of FlagsYAML must have a field of the same name in Flags
 type FlagsYAML struct {
 	ClientLocation                          *string  `yaml:"-"`
+	Binary                                  *string  `yaml:"-"`
 	Meta                                    *string  `yaml:"-"`
 	DataDir                                 *string  `yaml:"-"`
+	Proxied                                 *bool    `yaml:"-"`
 	AssertionsEnabled                       *bool    `yaml:"assertions-enabled,omitempty"`
 	Version                                 *bool    `yaml:"version,omitempty"`
 	TxFee                                   *uint    `yaml:"tx-fee,omitempty"`
 	PublicIP                                *string  `yaml:"public-ip,omitempty"`
+	DynamicUpdateDuration                   *string  `yaml:"dynamic-update-duration,omitempty"`
 	DynamicPublicIP                         *string  `yaml:"dynamic-public-ip,omitempty"`
 	NetworkID                               *string  `yaml:"network-id,omitempty"`
 	SignatureVerificationEnabled            *bool    `yaml:"signature-verification-enabled,omitempty"`
@@ -237,7 +248,7 @@ type FlagsYAML struct {
 	BenchlistMinFailingDuration             *string  `yaml:"benchlist-min-failing-duration,omitempty"`
 	BenchlistPeerSummaryEnabled             *bool    `yaml:"benchlist-peer-summary-enabled,omitempty"`
 	UptimeRequirement                       *float64 `yaml:"uptime-requirement,omitempty"`
-	RetryBootstrapWarnFrequency             *uint    `yaml:"bootstrap-retry-warn-frequency,omitempty"`
+	RetryBootstrapWarnFrequency             *int     `yaml:"bootstrap-retry-warn-frequency,omitempty"`
 	RetryBootstrap                          *bool    `yaml:"bootstrap-retry-enabled,omitempty"`
 	HealthCheckAveragerHalflifeKey          *string  `yaml:"health-check-averager-halflife,omitempty"`
 	HealthCheckFreqKey                      *string  `yaml:"health-check-frequency,omitempty"`
@@ -245,6 +256,11 @@ type FlagsYAML struct {
 	RouterHealthMaxDropRateKey              *float64 `yaml:"router-health-max-drop-rate,omitempty"`
 	IndexEnabled                            *bool    `yaml:"index-enabled,omitempty"`
 	PluginModeEnabled                       *bool    `yaml:"plugin-mode-enabled,omitempty"`
+	MeterVMsEnabled                         *bool    `yaml:"meter-vms-enabled,omitempty"`
+
+	// Passthrough
+	ExtraArgs  *string           `yaml:"-"`
+	ExtraFlags map[string]string `yaml:"extra-flags,omitempty"`
 }
 
 // SetDefaults sets any zero-value field to its default value
@@ -259,17 +275,21 @@ func (flags *Flags) SetDefaults() {
 }
 
 // ConvertYAML converts a FlagsYAML struct into a Flags struct
+// Fields are matched by name, and any field left unset takes its default value
 func ConvertYAML(flags FlagsYAML) Flags {
-	var result Flags
+	result := DefaultFlags()
 	res := reflect.Indirect(reflect.ValueOf(&result))
 	f := reflect.ValueOf(flags)
-	d := reflect.ValueOf(DefaultFlags())
-	for i := 0; i < res.NumField(); i++ {
-		if f.Field(i).IsNil() {
-			res.Field(i).Set(d.Field(i))
-		} else {
-			res.Field(i).Set(f.Field(i).Elem())
+	for i := 0; i < f.NumField(); i++ {
+		field := f.Field(i)
+		if field.IsNil() {
+			continue
+		}
+		if field.Kind() == reflect.Ptr {
+			field = field.Elem()
 		}
+		target := res.FieldByName(f.Type().Field(i).Name)
+		target.Set(field.Convert(target.Type()))
 	}
 	return result
 }
@@ -278,8 +298,12 @@ func ConvertYAML(flags FlagsYAML) Flags {
 func DefaultFlags() Flags {
 	return Flags{
 		ClientLocation:                          "",
+		Binary:                                  "",
 		Meta:                                    "",
 		DataDir:                                 "",
+		UseConfigFile:                           false,
+		Group:                                   "default",
+		Proxied:                                 false,
 		AssertionsEnabled:                       true,
 		Version:                                 false,
 		TxFee:                                   1000000,
@@ -366,5 +390,7 @@ func DefaultFlags() Flags {
 		IndexEnabled:                            false,
 		PluginModeEnabled:                       false,
 		MeterVMsEnabled:                         false,
+		ExtraArgs:                               "",
+		ExtraFlags:                              nil,
 	}
 }
@@ -0,0 +1,115 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigFileName is the name of the config file generated in a node's data directory
+const ConfigFileName = "config.json"
+
+// FlagsToConfig converts a `Flags` struct into an avalanchego JSON config.
+// Values set by avash take precedence over those of the `ConfigFile` flag,
+// mirroring CLI args overriding the config file when launching with args.
+func FlagsToConfig(flags Flags, basedir string) (map[string]interface{}, Metadata, error) {
+	values, metadata := flagValues(flags, basedir, false)
+	config := make(map[string]interface{})
+	if flags.ConfigFile != "" {
+		base, err := readConfigFile(flags.ConfigFile)
+		if err != nil {
+			return nil, metadata, err
+		}
+		config = base
+	}
+	for _, v := range values {
+		if v.name == "config-file" {
+			continue
+		}
+		if s, ok := v.value.(string); ok && s == "" {
+			continue
+		}
+		config[v.name] = v.value
+	}
+	// A flag without a value is set, as a bare `--flag` arg is
+	for name, value := range flags.ExtraFlags {
+		if value == "" {
+			config[strings.TrimLeft(name, "-")] = true
+			continue
+		}
+		config[strings.TrimLeft(name, "-")] = value
+	}
+	return config, metadata, nil
+}
+
+// FlagsToConfigArgs writes the JSON config of `flags` to the node's data
+// directory and returns the CLI args to launch the node with it
+func FlagsToConfigArgs(flags Flags, basedir string) ([]string, Metadata, error) {
+	config, metadata, err := FlagsToConfig(flags, basedir)
+	if err != nil {
+		return nil, metadata, err
+	}
+	configBytes, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return nil, metadata, err
+	}
+	if err := os.MkdirAll(basedir, os.ModePerm); err != nil {
+		return nil, metadata, err
+	}
+	configPath := filepath.Join(basedir, ConfigFileName)
+	if err := ioutil.WriteFile(configPath, configBytes, 0644); err != nil {
+		return nil, metadata, err
+	}
+	metadata.ConfigFile = configPath
+	args := append([]string{"--config-file=" + configPath}, strings.Fields(flags.ExtraArgs)...)
+	return args, metadata, nil
+}
+
+// ArgsToConfig returns the effective config of a node launched with `args`.
+// The contents of a `--config-file` arg are overridden by the other args.
+func ArgsToConfig(args []string) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--config-file=") {
+			base, err := readConfigFile(strings.TrimPrefix(arg, "--config-file="))
+			if err != nil {
+				return nil, err
+			}
+			config = base
+		}
+	}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+		if kv[0] == "config-file" {
+			continue
+		}
+		if len(kv) == 1 {
+			config[kv[0]] = true
+		} else {
+			config[kv[0]] = kv[1]
+		}
+	}
+	return config, nil
+}
+
+func readConfigFile(fp string) (map[string]interface{}, error) {
+	if fp != "" && string(fp[0]) != "/" {
+		wd, _ := os.Getwd()
+		fp = fmt.Sprintf("%s/%s", wd, fp)
+	}
+	configBytes, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %s", err.Error())
+	}
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", fp, err.Error())
+	}
+	return config, nil
+}
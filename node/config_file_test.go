@@ -0,0 +1,66 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagsToConfigArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avash-node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	userConfig := filepath.Join(dir, "user.json")
+	if err := ioutil.WriteFile(userConfig, []byte(`{"http-port": 1234, "snow-max-processing": 1024}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := DefaultFlags()
+	flags.ConfigFile = userConfig
+	flags.HTTPPort = 9660
+	flags.ExtraFlags = map[string]string{"network-allow-private-ips": "true", "index-allow-incomplete": ""}
+	flags.ExtraArgs = "--foo=bar"
+
+	basedir := filepath.Join(dir, "node1")
+	args, md, err := FlagsToConfigArgs(flags, basedir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(basedir, ConfigFileName)
+	assert.Equal(t, []string{"--config-file=" + configPath, "--foo=bar"}, args)
+	assert.Equal(t, configPath, md.ConfigFile)
+	assert.Equal(t, "9660", md.HTTPport)
+
+	config, err := ArgsToConfig(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, float64(9660), config["http-port"])
+	assert.Equal(t, float64(1024), config["snow-max-processing"])
+	assert.Equal(t, "true", config["network-allow-private-ips"])
+	assert.Equal(t, true, config["index-allow-incomplete"])
+	assert.Equal(t, "bar", config["foo"])
+	assert.Equal(t, basedir+"/db", config["db-dir"])
+	assert.NotContains(t, config, "config-file")
+	assert.NotContains(t, config, "bootstrap-ips")
+}
+
+func TestArgsToConfig(t *testing.T) {
+	args, _ := FlagsToArgs(DefaultFlags(), "/tmp/node", false)
+	config, err := ArgsToConfig(append(args, "--baz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "9650", config["http-port"])
+	assert.Equal(t, "/tmp/node/logs", config["log-dir"])
+	assert.Equal(t, true, config["baz"])
+
+	_, err = ArgsToConfig([]string{"--config-file=/nonexistent/config.json"})
+	assert.Error(t, err)
+}
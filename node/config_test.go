@@ -0,0 +1,36 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConvertYAML(t *testing.T) {
+	raw := `
+http-port: 9660
+staking-enabled: true
+bootstrap-retry-warn-frequency: 10
+meter-vms-enabled: true
+extra-flags:
+  network-allow-private-ips: true
+  snow-max-processing: 1024
+`
+	var flagsYAML FlagsYAML
+	if err := yaml.Unmarshal([]byte(raw), &flagsYAML); err != nil {
+		t.Fatal(err)
+	}
+	flags := ConvertYAML(flagsYAML)
+
+	expected := DefaultFlags()
+	expected.HTTPPort = 9660
+	expected.StakingEnabled = true
+	expected.RetryBootstrapWarnFrequency = 10
+	expected.MeterVMsEnabled = true
+	expected.ExtraFlags = map[string]string{
+		"network-allow-private-ips": "true",
+		"snow-max-processing":       "1024",
+	}
+	assert.Equal(t, expected, flags)
+}
@@ -6,6 +6,7 @@ type Metadata struct {
 	Stakingport    string `json:"staking-port"`
 	HTTPport       string `json:"http-port"`
 	HTTPTLS        bool   `json:"http-tls-enabled"`
+	HTTPTLSCert    string `json:"http-tls-cert-file,omitempty"`
 	Dbdir          string `json:"db-dir"`
 	Datadir        string `json:"data-dir"`
 	Logsdir        string `json:"log-dir"`
@@ -13,4 +14,5 @@ type Metadata struct {
 	StakingEnabled bool   `json:"staking-enabled"`
 	StakerCertPath string `json:"staking-tls-cert-file"`
 	StakerKeyPath  string `json:"staking-tls-key-file"`
+	ConfigFile     string `json:"config-file,omitempty"`
 }
@@ -0,0 +1,116 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConsensusProfile is a named set of snowball parameters
+// Zero-value fields are left unchanged when the profile is applied
+type ConsensusProfile struct {
+	SnowSampleSize              int `mapstructure:"snow-sample-size" yaml:"snow-sample-size,omitempty"`
+	SnowQuorumSize              int `mapstructure:"snow-quorum-size" yaml:"snow-quorum-size,omitempty"`
+	SnowVirtuousCommitThreshold int `mapstructure:"snow-virtuous-commit-threshold" yaml:"snow-virtuous-commit-threshold,omitempty"`
+	SnowRogueCommitThreshold    int `mapstructure:"snow-rogue-commit-threshold" yaml:"snow-rogue-commit-threshold,omitempty"`
+	SnowConcurrentRepolls       int `mapstructure:"snow-concurrent-repolls" yaml:"snow-concurrent-repolls,omitempty"`
+	SnowAvalancheBatchSize      int `mapstructure:"snow-avalanche-batch-size" yaml:"snow-avalanche-batch-size,omitempty"`
+	SnowAvalancheNumParents     int `mapstructure:"snow-avalanche-num-parents" yaml:"snow-avalanche-num-parents,omitempty"`
+}
+
+// Profiles are the built-in consensus profiles
+var Profiles = map[string]ConsensusProfile{
+	// Small samples and thresholds for quick finality on a handful of local nodes
+	"fast-local": {
+		SnowSampleSize:              2,
+		SnowQuorumSize:              2,
+		SnowVirtuousCommitThreshold: 2,
+		SnowRogueCommitThreshold:    3,
+		SnowConcurrentRepolls:       1,
+		SnowAvalancheBatchSize:      30,
+		SnowAvalancheNumParents:     5,
+	},
+	// The parameters used by nodes on mainnet
+	"mainnet-like": {
+		SnowSampleSize:              20,
+		SnowQuorumSize:              15,
+		SnowVirtuousCommitThreshold: 15,
+		SnowRogueCommitThreshold:    20,
+		SnowConcurrentRepolls:       4,
+		SnowAvalancheBatchSize:      30,
+		SnowAvalancheNumParents:     5,
+	},
+}
+
+// Apply sets the snowball parameters of `flags` to the non-zero values of the profile
+func (p ConsensusProfile) Apply(flags *Flags) {
+	for _, v := range []struct {
+		dst *int
+		val int
+	}{
+		{&flags.SnowSampleSize, p.SnowSampleSize},
+		{&flags.SnowQuorumSize, p.SnowQuorumSize},
+		{&flags.SnowVirtuousCommitThreshold, p.SnowVirtuousCommitThreshold},
+		{&flags.SnowRogueCommitThreshold, p.SnowRogueCommitThreshold},
+		{&flags.SnowConcurrentRepolls, p.SnowConcurrentRepolls},
+		{&flags.SnowAvalancheBatchSize, p.SnowAvalancheBatchSize},
+		{&flags.SnowAvalancheNumParents, p.SnowAvalancheNumParents},
+	} {
+		if v.val != 0 {
+			*v.dst = v.val
+		}
+	}
+}
+
+// Effective returns the profile applied on top of the default flags
+func (p ConsensusProfile) Effective() ConsensusProfile {
+	flags := DefaultFlags()
+	p.Apply(&flags)
+	return ConsensusProfile{
+		SnowSampleSize:              flags.SnowSampleSize,
+		SnowQuorumSize:              flags.SnowQuorumSize,
+		SnowVirtuousCommitThreshold: flags.SnowVirtuousCommitThreshold,
+		SnowRogueCommitThreshold:    flags.SnowRogueCommitThreshold,
+		SnowConcurrentRepolls:       flags.SnowConcurrentRepolls,
+		SnowAvalancheBatchSize:      flags.SnowAvalancheBatchSize,
+		SnowAvalancheNumParents:     flags.SnowAvalancheNumParents,
+	}
+}
+
+// Validate checks the profile applied on top of the default flags with
+// `Validate`
+func (p ConsensusProfile) Validate() error {
+	flags := DefaultFlags()
+	p.Apply(&flags)
+	return Validate(flags)
+}
+
+// LookupProfile returns the profile `name` from `custom`, falling back to the
+// built-in profiles. Names are case-insensitive, since viper lowercases the
+// keys of maps in the config file.
+func LookupProfile(name string, custom map[string]ConsensusProfile) (ConsensusProfile, error) {
+	name = strings.ToLower(name)
+	if p, ok := custom[name]; ok {
+		return p, nil
+	}
+	if p, ok := Profiles[name]; ok {
+		return p, nil
+	}
+	return ConsensusProfile{}, fmt.Errorf("unknown consensus profile: %s", name)
+}
+
+// ProfileNames returns the sorted names of the built-in and `custom` profiles
+func ProfileNames(custom map[string]ConsensusProfile) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range []map[string]ConsensusProfile{Profiles, custom} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
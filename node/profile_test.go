@@ -0,0 +1,36 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsensusProfile(t *testing.T) {
+	for name, p := range Profiles {
+		assert.NoError(t, p.Validate(), name)
+	}
+
+	custom := map[string]ConsensusProfile{
+		"partial":    {SnowSampleSize: 4, SnowQuorumSize: 3},
+		"fast-local": {SnowSampleSize: 1, SnowQuorumSize: 1},
+	}
+	p, err := LookupProfile("fast-local", custom)
+	assert.NoError(t, err)
+	assert.Equal(t, custom["fast-local"], p)
+	p, err = LookupProfile("Partial", custom)
+	assert.NoError(t, err)
+	assert.Equal(t, custom["partial"], p)
+	_, err = LookupProfile("missing", custom)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"fast-local", "mainnet-like", "partial"}, ProfileNames(custom))
+
+	flags := DefaultFlags()
+	custom["partial"].Apply(&flags)
+	assert.Equal(t, 4, flags.SnowSampleSize)
+	assert.Equal(t, 3, flags.SnowQuorumSize)
+	assert.Equal(t, DefaultFlags().SnowRogueCommitThreshold, flags.SnowRogueCommitThreshold)
+	assert.NoError(t, custom["partial"].Validate())
+	assert.Error(t, ConsensusProfile{SnowSampleSize: 4, SnowQuorumSize: 2}.Validate())
+	assert.Error(t, ConsensusProfile{SnowConcurrentRepolls: 100}.Validate())
+}
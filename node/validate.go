@@ -0,0 +1,170 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DBTypes are the database types accepted by `--db-type`
+var DBTypes = []string{"leveldb", "rocksdb", "memdb"}
+
+// Validate checks `flags` for values which would make avalanchego exit on
+// launch, and returns an error listing every rule that failed
+func Validate(flags Flags) error {
+	rulesfailed := consensusRules(
+		flags.SnowSampleSize,
+		flags.SnowQuorumSize,
+		flags.SnowVirtuousCommitThreshold,
+		flags.SnowRogueCommitThreshold,
+	)
+	if flags.SnowConcurrentRepolls <= 0 {
+		rulesfailed = append(rulesfailed, "snow-concurrent-repolls > 0")
+	}
+	if flags.SnowConcurrentRepolls > flags.SnowRogueCommitThreshold {
+		rulesfailed = append(rulesfailed, "snow-concurrent-repolls <= snow-rogue-commit-threshold")
+	}
+
+	// Ports
+	for _, p := range []struct {
+		name string
+		port uint
+	}{{"http-port", flags.HTTPPort}, {"staking-port", flags.StakingPort}} {
+		if p.port == 0 || p.port > 65535 {
+			rulesfailed = append(rulesfailed, fmt.Sprintf("%s in range 1-65535, got %d", p.name, p.port))
+		}
+	}
+	if flags.HTTPPort == flags.StakingPort {
+		rulesfailed = append(rulesfailed, "http-port != staking-port")
+	}
+
+	// TLS
+	if flags.HTTPTLSEnabled {
+		rulesfailed = append(rulesfailed, fileRules("http-tls-cert-file", flags.HTTPTLSCertFile, true)...)
+		rulesfailed = append(rulesfailed, fileRules("http-tls-key-file", flags.HTTPTLSKeyFile, true)...)
+	}
+	if flags.StakingEnabled {
+		// avalanchego generates a staking key pair when neither file is given
+		required := flags.StakingTLSCertFile != "" || flags.StakingTLSKeyFile != ""
+		rulesfailed = append(rulesfailed, fileRules("staking-tls-cert-file", flags.StakingTLSCertFile, required)...)
+		rulesfailed = append(rulesfailed, fileRules("staking-tls-key-file", flags.StakingTLSKeyFile, required)...)
+	}
+
+	// Database
+	if !isDBType(flags.DBType) {
+		rulesfailed = append(rulesfailed, fmt.Sprintf("db-type one of {%s}, got %q", strings.Join(DBTypes, ", "), flags.DBType))
+	}
+
+	// Durations
+	durations := make(map[string]time.Duration)
+	durationValues := durationFlags(flags)
+	var durationNames []string
+	for name := range durationValues {
+		durationNames = append(durationNames, name)
+	}
+	sort.Strings(durationNames)
+	for _, name := range durationNames {
+		value := durationValues[name]
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			rulesfailed = append(rulesfailed, fmt.Sprintf("%s is a duration, got %q", name, value))
+			continue
+		}
+		durations[name] = d
+	}
+	rulesfailed = append(rulesfailed, durationRules(durations, "min-stake-duration", "max-stake-duration")...)
+	rulesfailed = append(rulesfailed, durationRules(durations, "network-minimum-timeout", "network-maximum-timeout")...)
+
+	// Staking amounts
+	if flags.MinValidatorStake > flags.MaxValidatorStake {
+		rulesfailed = append(rulesfailed, "min-validator-stake <= max-validator-stake")
+	}
+	if flags.MinDelegatorStake > flags.MaxValidatorStake {
+		rulesfailed = append(rulesfailed, "min-delegator-stake <= max-validator-stake")
+	}
+
+	if len(rulesfailed) == 0 {
+		return nil
+	}
+	return errors.New("Invalid node flags: \n" + strings.Join(rulesfailed, "\n"))
+}
+
+func consensusRules(k int, alpha int, beta1 int, beta2 int) []string {
+	rulesfailed := []string(nil)
+	if k <= 0 {
+		rulesfailed = append(rulesfailed, "k > 0")
+	}
+	if alpha > k {
+		rulesfailed = append(rulesfailed, "alpha <= k")
+	}
+	if (k / 2) >= alpha {
+		rulesfailed = append(rulesfailed, "alpha > floor(k/2)")
+	}
+	if beta1 <= 0 {
+		rulesfailed = append(rulesfailed, "beta1 > 0")
+	}
+	if beta1 > beta2 {
+		rulesfailed = append(rulesfailed, "beta2 >= beta1")
+	}
+	return rulesfailed
+}
+
+func fileRules(name string, fp string, required bool) []string {
+	if fp == "" {
+		if required {
+			return []string{fmt.Sprintf("%s is set", name)}
+		}
+		return nil
+	}
+	if info, err := os.Stat(fp); err != nil || info.IsDir() {
+		return []string{fmt.Sprintf("%s exists, got %q", name, fp)}
+	}
+	return nil
+}
+
+func durationRules(durations map[string]time.Duration, min string, max string) []string {
+	lo, okLo := durations[min]
+	hi, okHi := durations[max]
+	if okLo && okHi && lo > hi {
+		return []string{fmt.Sprintf("%s <= %s", min, max)}
+	}
+	return nil
+}
+
+func durationFlags(flags Flags) map[string]string {
+	return map[string]string{
+		"dynamic-update-duration":                    flags.DynamicUpdateDuration,
+		"bootstrap-beacon-connection-timeout":        flags.BootstrapBeaconConnectionTimeout,
+		"consensus-shutdown-timeout":                 flags.ConsensusShutdownTimeout,
+		"consensus-gossip-frequency":                 flags.ConsensusGossipFrequency,
+		"max-stake-duration":                         flags.MaxStakeDuration,
+		"min-stake-duration":                         flags.MinStakeDuration,
+		"stake-minting-period":                       flags.StakeMintingPeriod,
+		"benchlist-duration":                         flags.BenchlistDuration,
+		"benchlist-min-failing-duration":             flags.BenchlistMinFailingDuration,
+		"network-initial-timeout":                    flags.NetworkInitialTimeout,
+		"network-minimum-timeout":                    flags.NetworkMinimumTimeout,
+		"network-maximum-timeout":                    flags.NetworkMaximumTimeout,
+		"network-health-max-time-since-msg-sent":     flags.NetworkHealthMaxTimeSinceMsgSentKey,
+		"network-health-max-time-since-msg-received": flags.NetworkHealthMaxTimeSinceMsgReceivedKey,
+		"network-timeout-halflife":                   flags.NetworkTimeoutHalflife,
+		"network-peer-list-gossip-frequency":         flags.NetworkPeerListGossipFrequency,
+		"health-check-averager-halflife":             flags.HealthCheckAveragerHalflifeKey,
+		"health-check-frequency":                     flags.HealthCheckFreqKey,
+	}
+}
+
+func isDBType(dbType string) bool {
+	for _, t := range DBTypes {
+		if t == dbType {
+			return true
+		}
+	}
+	return false
+}
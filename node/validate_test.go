@@ -0,0 +1,50 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(DefaultFlags()))
+
+	flags := DefaultFlags()
+	flags.StakingPort = flags.HTTPPort
+	flags.DBType = "boltdb"
+	flags.ConsensusGossipFrequency = "10"
+	flags.MinValidatorStake = flags.MaxValidatorStake + 1
+	flags.SnowConcurrentRepolls = flags.SnowRogueCommitThreshold + 1
+	flags.HTTPTLSEnabled = true
+	flags.StakingEnabled = true
+	flags.StakingTLSCertFile = "does/not/exist.crt"
+	err := Validate(flags)
+	if assert.Error(t, err) {
+		for _, rule := range []string{
+			"http-port != staking-port",
+			"db-type one of",
+			"consensus-gossip-frequency is a duration",
+			"min-validator-stake <= max-validator-stake",
+			"snow-concurrent-repolls <= snow-rogue-commit-threshold",
+			"http-tls-cert-file is set",
+			"staking-tls-cert-file exists",
+			"staking-tls-key-file is set",
+		} {
+			assert.Contains(t, err.Error(), rule)
+		}
+	}
+}
+
+func TestValidateConsensus(t *testing.T) {
+	consensus := func(k int, alpha int, beta1 int, beta2 int) Flags {
+		flags := DefaultFlags()
+		flags.SnowSampleSize = k
+		flags.SnowQuorumSize = alpha
+		flags.SnowVirtuousCommitThreshold = beta1
+		flags.SnowRogueCommitThreshold = beta2
+		return flags
+	}
+	assert.NoError(t, Validate(consensus(20, 16, 15, 20)))
+	assert.Error(t, Validate(consensus(20, 10, 15, 20)))
+	assert.Error(t, Validate(consensus(20, 16, 21, 20)))
+}
@@ -0,0 +1,337 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+// Package nodeapi issues requests to the APIs of the nodes managed by avash,
+// addressing each node by the name of its process.
+package nodeapi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// AuthEndpoint is the endpoint of the auth API, requests to which carry no
+// auth token since they're what mint them
+const AuthEndpoint = "ext/auth"
+
+// Target is where the API of a node is served
+type Target struct {
+	Host string
+	Port string
+	// TLS is whether the API is served over HTTPS
+	TLS bool
+	// CertFile is the certificate the API is served with. It's trusted along
+	// with the system roots, so self-signed certificates are accepted.
+	CertFile string
+}
+
+// URL returns the URL of `endpoint` on the target
+func (t Target) URL(endpoint string) string {
+	scheme := "http"
+	if t.TLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%s/%s", scheme, t.Host, t.Port, strings.TrimPrefix(endpoint, "/"))
+}
+
+// Logger is where requests and responses are logged
+type Logger interface {
+	Debug(format string, args ...interface{})
+}
+
+// Config configures a Client
+type Config struct {
+	// Resolve returns where the API of the named node is served
+	Resolve func(name string) (Target, error)
+	// Token returns the auth token to send to the named node, or "" if it
+	// doesn't require one
+	Token func(name string) (string, error)
+	// Refresh replaces the auth token of the named node after it's rejected
+	Refresh func(name string) (string, error)
+	// Timeout bounds each request, including its retries
+	Timeout time.Duration
+	// Retries is how many times to retry a request the node couldn't be
+	// reached for, waiting RetryDelay in between
+	Retries    int
+	RetryDelay time.Duration
+	// Log is where requests and responses are logged, if it isn't nil
+	Log Logger
+}
+
+// Client issues requests to the APIs of nodes by name
+type Client struct {
+	config Config
+
+	lock       sync.Mutex
+	transports map[string]*http.Transport
+}
+
+// New returns a Client configured by `config`
+func New(config Config) *Client {
+	return &Client{
+		config:     config,
+		transports: make(map[string]*http.Transport),
+	}
+}
+
+// URL returns the URL of `endpoint` on the named node
+func (c *Client) URL(name string, endpoint string) (string, error) {
+	target, err := c.config.Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	return target.URL(endpoint), nil
+}
+
+// HTTP returns an HTTP client for the API of the named node. It trusts the
+// node's certificate, authorizes requests with its auth token and retries
+// requests the node couldn't be reached for.
+func (c *Client) HTTP(name string) (*http.Client, error) {
+	target, err := c.config.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.transport(target)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &nodeTransport{client: c, name: name, base: base},
+		Timeout:   c.config.Timeout,
+	}, nil
+}
+
+// RPC returns a JSON-RPC client of `endpoint` on the named node
+func (c *Client) RPC(name string, endpoint string) (jsonrpc.RPCClient, error) {
+	url, err := c.URL(name, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := c.HTTP(name)
+	if err != nil {
+		return nil, err
+	}
+	return jsonrpc.NewClientWithOpts(url, &jsonrpc.RPCClientOpts{HTTPClient: httpClient}), nil
+}
+
+// Call issues a JSON-RPC call of `method` with `params` to `endpoint` on the
+// named node, decoding the result into `reply` if it isn't nil
+func (c *Client) Call(name string, endpoint string, method string, params interface{}, reply interface{}) error {
+	rpcClient, err := c.RPC(name, endpoint)
+	if err != nil {
+		return err
+	}
+	c.debug("%s %s %s %s", name, endpoint, method, encode(params))
+	response, err := rpcClient.Call(method, params)
+	if err != nil {
+		return fmt.Errorf("rpcClient returned error: %s", err.Error())
+	}
+	if response.Error != nil {
+		c.debug("%s %s %s failed: %d, %s", name, endpoint, method, response.Error.Code, response.Error.Message)
+		return fmt.Errorf("rpcClient returned error: %d, %s", response.Error.Code, response.Error.Message)
+	}
+	c.debug("%s %s %s returned %s", name, endpoint, method, encode(response.Result))
+	if reply == nil {
+		return nil
+	}
+	if err := response.GetObject(reply); err != nil {
+		return fmt.Errorf("error on parsing response: %s", err.Error())
+	}
+	return nil
+}
+
+// Get fetches `endpoint` on the named node, returning the body of the response
+func (c *Client) Get(name string, endpoint string) ([]byte, error) {
+	url, err := c.URL(name, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := c.HTTP(name)
+	if err != nil {
+		return nil, err
+	}
+	c.debug("%s GET %s", name, endpoint)
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.debug("%s GET %s returned %s, %d bytes", name, endpoint, resp.Status, len(body))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s of %s returned %s", endpoint, name, resp.Status)
+	}
+	return body, nil
+}
+
+// transport returns the base transport for `target`, which trusts its
+// certificate if it has one
+func (c *Client) transport(target Target) (*http.Transport, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := ""
+	if target.TLS {
+		key = target.CertFile
+	}
+	if t, ok := c.transports[key]; ok {
+		return t, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if key != "" {
+		pem, err := ioutil.ReadFile(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read certificate: %s", err.Error())
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", key)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	c.transports[key] = t
+	return t, nil
+}
+
+func (c *Client) debug(format string, args ...interface{}) {
+	if c.config.Log != nil {
+		c.config.Log.Debug(format, args...)
+	}
+}
+
+// nodeTransport authorizes and retries the requests to the API of a node
+type nodeTransport struct {
+	client *Client
+	name   string
+	base   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *nodeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	config := t.client.config
+	authorized := !strings.HasPrefix(strings.TrimPrefix(req.URL.Path, "/"), AuthEndpoint)
+	token := ""
+	if authorized && config.Token != nil {
+		var err error
+		if token, err = config.Token(t.name); err != nil {
+			return nil, fmt.Errorf("unable to get auth token for %s: %s", t.name, err.Error())
+		}
+	}
+	resp, err := t.send(req, body, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !authorized || config.Refresh == nil {
+		return resp, err
+	}
+	// The token expired or was revoked elsewhere, so mint a new one
+	if token, err = config.Refresh(t.name); err != nil {
+		t.client.debug("unable to refresh auth token for %s: %s", t.name, err.Error())
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.send(req, body, token)
+}
+
+// send sends a copy of `req` with `body`, retrying it while the node can't be
+// reached
+func (t *nodeTransport) send(req *http.Request, body []byte, token string) (*http.Response, error) {
+	config := t.client.config
+	for attempt := 0; ; attempt++ {
+		r := req.Clone(req.Context())
+		if body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := t.base.RoundTrip(r)
+		if err == nil || attempt >= config.Retries || !isDialError(err) {
+			return resp, err
+		}
+		t.client.debug("retrying %s after %s", t.name, err.Error())
+		select {
+		case <-time.After(config.RetryDelay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// isDialError returns whether `err` is a failure to connect, in which case the
+// request wasn't sent and is safe to retry
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// secretFields are the params and result fields which hold credentials, keys
+// or keystore contents, and are never logged
+var secretFields = map[string]bool{
+	"password":    true,
+	"oldpassword": true,
+	"newpassword": true,
+	"privatekey":  true,
+	"token":       true,
+	"user":        true,
+}
+
+// encode returns `v` as JSON for logging, with secret fields redacted
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("(%T)", v)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return fmt.Sprintf("(%d bytes)", len(b))
+	}
+	redacted, err := json.Marshal(redact(decoded))
+	if err != nil {
+		return fmt.Sprintf("(%d bytes)", len(b))
+	}
+	return string(redacted)
+}
+
+// redact replaces the values of the secret fields in the decoded JSON value
+// `v`, recursively
+func redact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			if secretFields[strings.ToLower(k)] {
+				v[k] = "[redacted]"
+			} else {
+				v[k] = redact(elem)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redact(elem)
+		}
+	}
+	return v
+}
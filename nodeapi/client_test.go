@@ -0,0 +1,206 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+package nodeapi
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// echoHandler answers JSON-RPC calls with their method and params, and
+// rejects requests not authorized with `token` if it's set
+func echoHandler(token *string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *token != "" && r.Header.Get("Authorization") != "Bearer "+*token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method == "fail" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"failed"}}`, req.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"method":%q,"params":%s}}`, req.ID, req.Method, req.Params)
+	})
+}
+
+// serverTarget returns the target of the test server `s`
+func serverTarget(t *testing.T, s *httptest.Server) Target {
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	host, port, err := net.SplitHostPort(u.Host)
+	assert.NoError(t, err)
+	return Target{Host: host, Port: port, TLS: u.Scheme == "https"}
+}
+
+func TestCall(t *testing.T) {
+	token := ""
+	s := httptest.NewServer(echoHandler(&token))
+	defer s.Close()
+	target := serverTarget(t, s)
+	c := New(Config{
+		Resolve: func(name string) (Target, error) {
+			if name != "n1" {
+				return Target{}, fmt.Errorf("process not found: %s", name)
+			}
+			return target, nil
+		},
+	})
+
+	var reply struct {
+		Method string            `json:"method"`
+		Params map[string]string `json:"params"`
+	}
+	assert.NoError(t, c.Call("n1", "ext/info", "info.getNodeID", map[string]string{"a": "b"}, &reply))
+	assert.Equal(t, "info.getNodeID", reply.Method)
+	assert.Equal(t, map[string]string{"a": "b"}, reply.Params)
+
+	assert.EqualError(t, c.Call("n1", "ext/info", "fail", struct{}{}, nil), "rpcClient returned error: -32000, failed")
+	assert.Error(t, c.Call("n2", "ext/info", "info.getNodeID", struct{}{}, nil))
+
+	u, err := c.URL("n1", "/ext/bc/X")
+	assert.NoError(t, err)
+	assert.Equal(t, s.URL+"/ext/bc/X", u)
+}
+
+func TestAuthToken(t *testing.T) {
+	token := "new"
+	s := httptest.NewServer(echoHandler(&token))
+	defer s.Close()
+	target := serverTarget(t, s)
+	current, refreshes := "old", 0
+	c := New(Config{
+		Resolve: func(string) (Target, error) { return target, nil },
+		Token:   func(string) (string, error) { return current, nil },
+		Refresh: func(string) (string, error) {
+			refreshes++
+			current = "new"
+			return current, nil
+		},
+	})
+
+	assert.NoError(t, c.Call("n1", "ext/info", "info.getNodeID", struct{}{}, nil))
+	assert.Equal(t, 1, refreshes)
+	assert.NoError(t, c.Call("n1", "ext/info", "info.getNodeID", struct{}{}, nil))
+	assert.Equal(t, 1, refreshes)
+
+	// The auth API itself is called without a token
+	token = ""
+	c.config.Token = func(string) (string, error) { return "", fmt.Errorf("no token") }
+	assert.NoError(t, c.Call("n1", AuthEndpoint, "auth.newToken", struct{}{}, nil))
+	assert.Error(t, c.Call("n1", "ext/info", "info.getNodeID", struct{}{}, nil))
+}
+
+func TestTLS(t *testing.T) {
+	token := ""
+	s := httptest.NewTLSServer(echoHandler(&token))
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "avash-nodeapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	assert.NoError(t, ioutil.WriteFile(certFile, cert, 0600))
+
+	target := serverTarget(t, s)
+	c := New(Config{Resolve: func(string) (Target, error) { return target, nil }})
+	assert.Error(t, c.Call("n1", "ext/info", "info.getNodeID", struct{}{}, nil))
+
+	target.CertFile = certFile
+	assert.NoError(t, c.Call("n1", "ext/info", "info.getNodeID", struct{}{}, nil))
+}
+
+func TestGet(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ext/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "avalanche_network_peers 4\n")
+	}))
+	defer s.Close()
+	target := serverTarget(t, s)
+	c := New(Config{Resolve: func(string) (Target, error) { return target, nil }})
+
+	body, err := c.Get("n1", "ext/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, "avalanche_network_peers 4\n", string(body))
+	_, err = c.Get("n1", "ext/health")
+	assert.Error(t, err)
+}
+
+func TestRetries(t *testing.T) {
+	// Reserve a port and close it so connections to it are refused
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+
+	attempts := 0
+	c := New(Config{
+		Resolve: func(string) (Target, error) {
+			return Target{Host: "127.0.0.1", Port: port}, nil
+		},
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+		Log:        logFunc(func(string, ...interface{}) { attempts++ }),
+	})
+	assert.Error(t, c.Call("n1", "ext/info", "info.getNodeID", struct{}{}, nil))
+	// The request is logged once and each of the retries once
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRedactedLog(t *testing.T) {
+	token := ""
+	s := httptest.NewServer(echoHandler(&token))
+	defer s.Close()
+
+	var logged []string
+	c := New(Config{
+		Resolve: func(string) (Target, error) { return serverTarget(t, s), nil },
+		Log: logFunc(func(format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		}),
+	})
+	params := map[string]interface{}{
+		"username":   "u",
+		"password":   "hunter2",
+		"privateKey": "PrivateKey-secret",
+		"keys":       []interface{}{map[string]string{"Token": "t0k3n"}},
+	}
+	assert.NoError(t, c.Call("n1", "ext/keystore", "keystore.createUser", params, nil))
+	assert.Len(t, logged, 2)
+	for _, line := range logged {
+		assert.Contains(t, line, `"username":"u"`)
+		for _, secret := range []string{"hunter2", "PrivateKey-secret", "t0k3n"} {
+			assert.NotContains(t, line, secret)
+		}
+	}
+	// The params passed in are left as they were
+	assert.Equal(t, "hunter2", params["password"])
+}
+
+type logFunc func(format string, args ...interface{})
+
+func (f logFunc) Debug(format string, args ...interface{}) { f(format, args...) }
@@ -9,8 +9,10 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/node"
 )
 
 // InputHandler is a generic function for handling input from cin
@@ -27,8 +29,10 @@ type Process struct {
 	name      string
 	proctype  string
 	metadata  string
+	nodeFlags *node.Flags
 	running   bool
 	failed    bool
+	exited    chan struct{}
 	output    io.ReadCloser
 	errput    io.ReadCloser
 	input     io.WriteCloser
@@ -43,6 +47,25 @@ type Process struct {
 	errhandle OutputHandler
 }
 
+// binary returns the name and version of the avalanchego binary a node
+// process runs, or an empty string for other processes
+func (p *Process) binary() string {
+	if p.nodeFlags == nil {
+		return ""
+	}
+	name := "default"
+	switch {
+	case p.nodeFlags.ClientLocation != "":
+		name = "custom"
+	case p.nodeFlags.Binary != "":
+		name = p.nodeFlags.Binary
+	}
+	if v, err := node.DetectVersion(p.cmdstr); err == nil {
+		name += " (" + v.String() + ")"
+	}
+	return name
+}
+
 // Start begins a new process
 func (p *Process) Start(done chan bool) {
 	log := cfg.Config.Log
@@ -56,7 +79,10 @@ func (p *Process) Start(done chan bool) {
 	log.Info("Command: %s\n", p.cmd.Args)
 
 	selfStopped := false
+	exited := make(chan struct{})
+	p.exited = exited
 	go func() {
+		defer close(exited)
 		err := p.cmd.Start()
 		if err != nil {
 			p.fail <- err
@@ -156,6 +182,20 @@ func (p *Process) Kill() error {
 	return nil
 }
 
+// WaitForExit blocks until the last started command has exited, returning an
+// error if it is still running after `timeout`
+func (p *Process) WaitForExit(timeout time.Duration) error {
+	if p.exited == nil {
+		return nil
+	}
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("Timed out waiting for process to exit: %s", p.name)
+	}
+}
+
 func (p *Process) endProcess(killer bool) error {
 	if killer {
 		if err := p.cmd.Process.Kill(); err != nil {
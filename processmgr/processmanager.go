@@ -6,9 +6,12 @@ package processmgr
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ava-labs/avash/cfg"
+	"github.com/ava-labs/avash/node"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -77,6 +80,14 @@ func (pm *ProcessManager) StopProcess(name string) error {
 	return p.Stop()
 }
 
+// StopProcessAndWait stops the process at the name and blocks until it has exited
+func (pm *ProcessManager) StopProcessAndWait(name string, timeout time.Duration) error {
+	if err := pm.StopProcess(name); err != nil {
+		return err
+	}
+	return pm.processes[name].WaitForExit(timeout)
+}
+
 // StopAllProcesses calls Stop() on every running process, logging errors
 func (pm *ProcessManager) StopAllProcesses() {
 	existsRunning := false
@@ -169,15 +180,17 @@ func (pm *ProcessManager) RemoveAllProcesses() {
 
 // ProcessTable returns a formatted metadata table for the data provided
 func (pm *ProcessManager) ProcessTable(table *tablewriter.Table) *tablewriter.Table {
-	table.SetHeader([]string{"Name", "Status", "Metadata", "Command"})
+	table.SetHeader([]string{"Name", "Status", "Binary", "Metadata", "Command"})
 	table.SetBorder(false)
 
 	table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold, tablewriter.BgBlueColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.BgMagentaColor, tablewriter.FgWhiteColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.BgMagentaColor, tablewriter.FgWhiteColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.BgMagentaColor, tablewriter.FgWhiteColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.BgMagentaColor, tablewriter.FgWhiteColor})
 
 	table.SetColumnColor(tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Normal},
 		tablewriter.Colors{tablewriter.Normal},
 		tablewriter.Colors{tablewriter.Normal},
 		tablewriter.Colors{tablewriter.Normal})
@@ -200,7 +213,7 @@ func (pm *ProcessManager) ProcessSummary() *[][]string {
 			running = "stopped"
 		}
 		cmd := val.cmdstr + " " + strings.Join(val.args, " ")
-		line := []string{val.name, running, val.metadata, cmd}
+		line := []string{val.name, running, val.binary(), val.metadata, cmd}
 		data = append(data, line)
 	}
 	return &data
@@ -218,6 +231,61 @@ func (pm *ProcessManager) Metadata(name string) (string, error) {
 	return "", fmt.Errorf("Process does not exist, cannot get metadata: %s", name)
 }
 
+// Args returns the command args given the process name
+func (pm *ProcessManager) Args(name string) ([]string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("Process name required")
+	}
+	if p, ok := pm.processes[name]; ok {
+		return p.args, nil
+	}
+	return nil, fmt.Errorf("Process does not exist, cannot get args: %s", name)
+}
+
+// SetNodeFlags records the node flags the named process was created from
+func (pm *ProcessManager) SetNodeFlags(name string, flags node.Flags) error {
+	p, ok := pm.processes[name]
+	if !ok {
+		return fmt.Errorf("Process does not exist, cannot set node flags: %s", name)
+	}
+	p.nodeFlags = &flags
+	return nil
+}
+
+// NodeFlags returns the node flags the named process was created from
+func (pm *ProcessManager) NodeFlags(name string) (node.Flags, error) {
+	if name == "" {
+		return node.Flags{}, fmt.Errorf("Process name required")
+	}
+	p, ok := pm.processes[name]
+	if !ok {
+		return node.Flags{}, fmt.Errorf("Process does not exist, cannot get node flags: %s", name)
+	}
+	if p.nodeFlags == nil {
+		return node.Flags{}, fmt.Errorf("Process has no node flags: %s", name)
+	}
+	return *p.nodeFlags, nil
+}
+
+// Names returns the names of all processes in sorted order
+func (pm *ProcessManager) Names() []string {
+	var names []string
+	for name := range pm.processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsRunning returns true if the named process is running, otherwise false
+func (pm *ProcessManager) IsRunning(name string) (bool, error) {
+	p, ok := pm.processes[name]
+	if !ok {
+		return false, fmt.Errorf("Process does not exist: %s", name)
+	}
+	return p.running, nil
+}
+
 // HasRunning returns true if there exists a running process, otherwise false
 func (pm *ProcessManager) HasRunning() bool {
 	for _, val := range pm.processes {
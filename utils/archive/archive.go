@@ -0,0 +1,173 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+// Package archive creates and extracts gzipped tarballs of directories
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Create writes a gzipped tarball to `dst` containing `paths`, which are
+// relative to `root`. Paths which don't exist are skipped.
+func Create(dst string, root string, paths []string) error {
+	return CreateWithFiles(dst, root, paths, nil)
+}
+
+// CreateWithFiles is like Create, additionally writing the contents of `files`
+// into the tarball under their keys. The tarball is written next to `dst` and
+// only replaces it once complete, so a failure leaves an existing `dst` intact.
+func CreateWithFiles(dst string, root string, paths []string, files map[string][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = write(f, root, paths, files)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// write writes the gzipped tarball of `paths` and `files` to `w`
+func write(w io.Writer, root string, paths []string, files map[string][]byte) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(root, p)); os.IsNotExist(err) {
+			continue
+		}
+		if err := filepath.Walk(filepath.Join(root, p), func(fp string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return addFile(tw, root, fp, info)
+		}); err != nil {
+			return err
+		}
+	}
+	for name, data := range files {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func addFile(tw *tar.Writer, root string, fp string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		return nil
+	}
+	rel, err := filepath.Rel(root, fp)
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+	src, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// Extract unpacks the gzipped tarball at `src` into `root`
+func Extract(src string, root string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fp := filepath.Join(root, filepath.FromSlash(hdr.Name))
+		if fp != filepath.Clean(root) && !strings.HasPrefix(fp, filepath.Clean(root)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fp, os.FileMode(hdr.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, fp, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractFile(r io.Reader, fp string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(fp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// Size returns the total size in bytes of the files under `root`
+func Size(root string) int64 {
+	var size int64
+	filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,73 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExtract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avash-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "db", "v1"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "db", "v1", "data"), []byte("state"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "other"), []byte("skipped"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tarball := filepath.Join(dir, "snapshots", "snap.tar.gz")
+	files := map[string][]byte{"manifest.json": []byte("{}")}
+	if err := CreateWithFiles(tarball, src, []string{"db", "logs"}, files); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := Extract(tarball, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dst, "db", "v1", "data"))
+	assert.NoError(t, err)
+	assert.Equal(t, "state", string(data))
+	assert.NoFileExists(t, filepath.Join(dst, "other"))
+	manifest, err := ioutil.ReadFile(filepath.Join(dst, "manifest.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(manifest))
+	assert.Equal(t, int64(7), Size(dst))
+}
+
+func TestCreateFailureKeepsExisting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avash-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tarball := filepath.Join(dir, "snap.tar.gz")
+	if err := CreateWithFiles(tarball, dir, nil, map[string][]byte{"old": []byte("old")}); err != nil {
+		t.Fatal(err)
+	}
+	// A NUL byte can't be encoded in a tar header name
+	bad := map[string][]byte{"bad\x00name": []byte("new")}
+	assert.Error(t, CreateWithFiles(tarball, dir, nil, bad))
+	assert.NoFileExists(t, tarball+".tmp")
+
+	dst := filepath.Join(dir, "dst")
+	if err := Extract(tarball, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dst, "old"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(data))
+}
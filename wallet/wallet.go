@@ -0,0 +1,401 @@
+// Copyright © 2021 AVA Labs, Inc.
+// All rights reserved.
+
+// Package wallet holds private keys and builds signed X-chain transactions
+// spending the UTXOs they control
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/nftfx"
+	"github.com/ava-labs/avalanchego/vms/propertyfx"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"golang.org/x/crypto/sha3"
+)
+
+// codecVersion is the version of the X-chain codec
+const codecVersion = 0
+
+// Chain is the X-chain a wallet issues transactions to
+type Chain struct {
+	ID          ids.ID
+	Alias       string
+	AVAXAssetID ids.ID
+	TxFee       uint64
+}
+
+// Wallet holds private keys and tracks the UTXOs they control on one chain
+type Wallet struct {
+	Name      string
+	NetworkID uint32
+	Chain     Chain
+
+	codec    codec.Manager
+	keychain *secp256k1fx.Keychain
+	utxos    map[ids.ID]*avax.UTXO
+	pending  map[ids.ID][]*avax.UTXO
+}
+
+// New returns an empty wallet for the network `networkID`
+func New(name string, networkID uint32) (*Wallet, error) {
+	c, err := newCodec()
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{
+		Name:      name,
+		NetworkID: networkID,
+		Chain:     Chain{Alias: "X"},
+		codec:     c,
+		keychain:  secp256k1fx.NewKeychain(),
+		utxos:     make(map[ids.ID]*avax.UTXO),
+		pending:   make(map[ids.ID][]*avax.UTXO),
+	}, nil
+}
+
+// newCodec returns the codec of the X-chain with its default feature
+// extensions
+func newCodec() (codec.Manager, error) {
+	_, c, err := avm.NewCodecs([]avm.Fx{
+		&secp256k1fx.Fx{},
+		&nftfx.Fx{},
+		&propertyfx.Fx{},
+	})
+	return c, err
+}
+
+// Codec returns the codec of the wallet's chain
+func (w *Wallet) Codec() codec.Manager {
+	return w.codec
+}
+
+// ParsePrivateKey parses a "PrivateKey-" prefixed CB58 private key
+func ParsePrivateKey(s string) (*crypto.PrivateKeySECP256K1R, error) {
+	b, err := formatting.Decode(formatting.CB58, strings.TrimPrefix(s, "PrivateKey-"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err.Error())
+	}
+	factory := crypto.FactorySECP256K1R{}
+	sk, err := factory.ToPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err.Error())
+	}
+	return sk.(*crypto.PrivateKeySECP256K1R), nil
+}
+
+// AddKey adds `sk` to the wallet and returns its address
+func (w *Wallet) AddKey(sk *crypto.PrivateKeySECP256K1R) (string, error) {
+	w.keychain.Add(sk)
+	return w.FormatAddress(sk.PublicKey().Address())
+}
+
+// Keys returns the private keys of the wallet, in the order they were added
+func (w *Wallet) Keys() []*crypto.PrivateKeySECP256K1R {
+	return w.keychain.Keys
+}
+
+// Addresses returns the addresses of the keys in the wallet
+func (w *Wallet) Addresses() []string {
+	var addrs []string
+	for _, sk := range w.keychain.Keys {
+		addr, _ := w.FormatAddress(sk.PublicKey().Address())
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// FormatAddress returns the chain-prefixed bech32 representation of `addr`
+func (w *Wallet) FormatAddress(addr ids.ShortID) (string, error) {
+	return w.FormatChainAddress(w.Chain.Alias, addr)
+}
+
+// FormatChainAddress returns the bech32 representation of `addr` on the
+// chain `alias`
+func (w *Wallet) FormatChainAddress(alias string, addr ids.ShortID) (string, error) {
+	return formatting.FormatAddress(alias, constants.GetHRP(w.NetworkID), addr.Bytes())
+}
+
+// EthAddress returns the hex encoded Ethereum address of `pk`, as used by the
+// C-chain
+func EthAddress(pk crypto.PublicKey) string {
+	ecdsaKey := pk.(*crypto.PublicKeySECP256K1R).ToECDSA()
+	b := make([]byte, 64)
+	ecdsaKey.X.FillBytes(b[:32])
+	ecdsaKey.Y.FillBytes(b[32:])
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(b)
+	return "0x" + hex.EncodeToString(hash.Sum(nil)[12:])
+}
+
+// ParseAddress parses a chain-prefixed bech32 address
+func (w *Wallet) ParseAddress(s string) (ids.ShortID, error) {
+	_, hrp, b, err := formatting.ParseAddress(s)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	if expected := constants.GetHRP(w.NetworkID); hrp != expected {
+		return ids.ShortEmpty, fmt.Errorf("address %s is for network %q, expected %q", s, hrp, expected)
+	}
+	return ids.ToShortID(b)
+}
+
+// SetUTXOs replaces the UTXOs tracked by the wallet with `utxos`, in their
+// serialized form, discarding any pending transactions
+func (w *Wallet) SetUTXOs(utxos [][]byte) error {
+	w.utxos = make(map[ids.ID]*avax.UTXO)
+	w.pending = make(map[ids.ID][]*avax.UTXO)
+	for _, b := range utxos {
+		utxo := &avax.UTXO{}
+		if _, err := w.codec.Unmarshal(b, utxo); err != nil {
+			return fmt.Errorf("invalid UTXO: %s", err.Error())
+		}
+		w.utxos[utxo.InputID()] = utxo
+	}
+	return nil
+}
+
+// UTXOs returns the unspent outputs tracked by the wallet, sorted by ID
+func (w *Wallet) UTXOs() []*avax.UTXO {
+	var utxos []*avax.UTXO
+	for _, utxo := range w.utxos {
+		utxos = append(utxos, utxo)
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		a, b := utxos[i].InputID(), utxos[j].InputID()
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	return utxos
+}
+
+// Balance returns the spendable amount of `assetID` held by the wallet
+func (w *Wallet) Balance(assetID ids.ID) uint64 {
+	balances := w.Balances()
+	return balances[assetID]
+}
+
+// Balances returns the spendable amount of each asset held by the wallet
+func (w *Wallet) Balances() map[ids.ID]uint64 {
+	now := uint64(time.Now().Unix())
+	balances := make(map[ids.ID]uint64)
+	for _, utxo := range w.utxos {
+		if _, _, err := w.keychain.Spend(utxo.Out, now); err != nil {
+			continue
+		}
+		if out, ok := utxo.Out.(avax.TransferableOut); ok {
+			balances[utxo.AssetID()] += out.Amount()
+		}
+	}
+	return balances
+}
+
+// CreateTx returns a signed transaction sending `amount` of `assetID` to
+// `to`, with change returned to the wallet's first address. The spent UTXOs
+// are held by the wallet until the transaction is removed or the wallet is
+// refreshed.
+func (w *Wallet) CreateTx(assetID ids.ID, amount uint64, to ids.ShortID) (*avm.Tx, error) {
+	if w.Chain.ID == ids.Empty {
+		return nil, errors.New("wallet chain unknown, refresh the wallet first")
+	}
+	if len(w.keychain.Keys) == 0 {
+		return nil, errors.New("wallet has no keys")
+	}
+	outs := []*avax.TransferableOutput{transferOutput(assetID, amount, to)}
+	ins, signers, spent, change, err := w.spend(map[ids.ID]uint64{assetID: amount})
+	if err != nil {
+		return nil, err
+	}
+	outs = append(outs, change...)
+	return w.sign(&avm.BaseTx{BaseTx: avax.BaseTx{
+		NetworkID:    w.NetworkID,
+		BlockchainID: w.Chain.ID,
+		Outs:         outs,
+		Ins:          ins,
+	}}, signers, spent)
+}
+
+// spend selects UTXOs covering `amounts` plus the transaction fee, returning
+// the inputs consuming them, their signers and outputs for the change
+func (w *Wallet) spend(amounts map[ids.ID]uint64) ([]*avax.TransferableInput, [][]*crypto.PrivateKeySECP256K1R, []*avax.UTXO, []*avax.TransferableOutput, error) {
+	needed := make(map[ids.ID]uint64)
+	for assetID, amount := range amounts {
+		needed[assetID] = amount
+	}
+	needed[w.Chain.AVAXAssetID] += w.Chain.TxFee
+
+	now := uint64(time.Now().Unix())
+	changeAddr := w.keychain.Keys[0].PublicKey().Address()
+	var (
+		ins     []*avax.TransferableInput
+		signers [][]*crypto.PrivateKeySECP256K1R
+		spent   []*avax.UTXO
+		change  []*avax.TransferableOutput
+	)
+	for _, utxo := range w.UTXOs() {
+		assetID := utxo.AssetID()
+		remaining, ok := needed[assetID]
+		if !ok || remaining == 0 {
+			continue
+		}
+		in, keys, err := w.keychain.Spend(utxo.Out, now)
+		if err != nil {
+			continue
+		}
+		input, ok := in.(avax.TransferableIn)
+		if !ok {
+			continue
+		}
+		ins = append(ins, &avax.TransferableInput{
+			UTXOID: utxo.UTXOID,
+			Asset:  utxo.Asset,
+			In:     input,
+		})
+		signers = append(signers, keys)
+		spent = append(spent, utxo)
+		if input.Amount() > remaining {
+			change = append(change, transferOutput(assetID, input.Amount()-remaining, changeAddr))
+			remaining = 0
+		} else {
+			remaining -= input.Amount()
+		}
+		needed[assetID] = remaining
+	}
+	for assetID, remaining := range needed {
+		if remaining > 0 {
+			return nil, nil, nil, nil, fmt.Errorf("insufficient funds: missing %d of asset %s", remaining, assetID)
+		}
+	}
+	avax.SortTransferableInputsWithSigners(ins, signers)
+	return ins, signers, spent, change, nil
+}
+
+// sign signs `utx` and records the UTXOs it spends and creates
+func (w *Wallet) sign(utx avm.UnsignedTx, signers [][]*crypto.PrivateKeySECP256K1R, spent []*avax.UTXO) (*avm.Tx, error) {
+	if base, ok := utx.(*avm.BaseTx); ok {
+		avax.SortTransferableOutputs(base.Outs, w.codec)
+	}
+	tx := &avm.Tx{UnsignedTx: utx}
+	if err := tx.SignSECP256K1Fx(w.codec, signers); err != nil {
+		return nil, err
+	}
+	for _, utxo := range spent {
+		delete(w.utxos, utxo.InputID())
+	}
+	w.pending[tx.ID()] = spent
+	for _, utxo := range utx.UTXOs() {
+		if _, _, err := w.keychain.Spend(utxo.Out, uint64(time.Now().Unix())); err == nil {
+			w.utxos[utxo.InputID()] = utxo
+		}
+	}
+	return tx, nil
+}
+
+// ParseTx parses a signed transaction of the wallet's chain
+func (w *Wallet) ParseTx(b []byte) (*avm.Tx, error) {
+	return parseTx(w.codec, b)
+}
+
+// ParseTx parses a signed X-chain transaction
+func ParseTx(b []byte) (*avm.Tx, error) {
+	c, err := newCodec()
+	if err != nil {
+		return nil, err
+	}
+	return parseTx(c, b)
+}
+
+func parseTx(c codec.Manager, b []byte) (*avm.Tx, error) {
+	tx := &avm.Tx{}
+	if _, err := c.Unmarshal(b, tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %s", err.Error())
+	}
+	unsignedBytes, err := c.Marshal(codecVersion, &tx.UnsignedTx)
+	if err != nil {
+		return nil, err
+	}
+	tx.Initialize(unsignedBytes, b)
+	return tx, nil
+}
+
+// Fee returns the amount of `feeAssetID` burned by `tx`, the difference
+// between what it consumes and what it produces
+func Fee(tx *avm.Tx, feeAssetID ids.ID) uint64 {
+	var (
+		ins  [][]*avax.TransferableInput
+		outs [][]*avax.TransferableOutput
+	)
+	switch utx := tx.UnsignedTx.(type) {
+	case *avm.BaseTx:
+		ins, outs = [][]*avax.TransferableInput{utx.Ins}, [][]*avax.TransferableOutput{utx.Outs}
+	case *avm.CreateAssetTx:
+		ins, outs = [][]*avax.TransferableInput{utx.Ins}, [][]*avax.TransferableOutput{utx.Outs}
+	case *avm.OperationTx:
+		ins, outs = [][]*avax.TransferableInput{utx.Ins}, [][]*avax.TransferableOutput{utx.Outs}
+	case *avm.ImportTx:
+		ins, outs = [][]*avax.TransferableInput{utx.Ins, utx.ImportedIns}, [][]*avax.TransferableOutput{utx.Outs}
+	case *avm.ExportTx:
+		ins, outs = [][]*avax.TransferableInput{utx.Ins}, [][]*avax.TransferableOutput{utx.Outs, utx.ExportedOuts}
+	}
+	var consumed, produced uint64
+	for _, set := range ins {
+		for _, in := range set {
+			if in.AssetID() == feeAssetID {
+				consumed += in.In.Amount()
+			}
+		}
+	}
+	for _, set := range outs {
+		for _, out := range set {
+			if out.AssetID() == feeAssetID {
+				produced += out.Out.Amount()
+			}
+		}
+	}
+	if produced > consumed {
+		return 0
+	}
+	return consumed - produced
+}
+
+// RemoveTx forgets the pending transaction `tx`, making the UTXOs it spent
+// available again and dropping the UTXOs it created
+func (w *Wallet) RemoveTx(tx *avm.Tx) error {
+	spent, ok := w.pending[tx.ID()]
+	if !ok {
+		return fmt.Errorf("transaction not pending in wallet %s: %s", w.Name, tx.ID())
+	}
+	for _, utxo := range tx.UTXOs() {
+		delete(w.utxos, utxo.InputID())
+	}
+	for _, utxo := range spent {
+		w.utxos[utxo.InputID()] = utxo
+	}
+	delete(w.pending, tx.ID())
+	return nil
+}
+
+func transferOutput(assetID ids.ID, amount uint64, to ids.ShortID) *avax.TransferableOutput {
+	return &avax.TransferableOutput{
+		Asset: avax.Asset{ID: assetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt: amount,
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{to},
+			},
+		},
+	}
+}
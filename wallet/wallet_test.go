@@ -0,0 +1,85 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/stretchr/testify/assert"
+)
+
+const ewoqKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+
+func testWallet(t *testing.T, amounts ...uint64) *Wallet {
+	w, err := New("test", constants.LocalID)
+	assert.NoError(t, err)
+	sk, err := ParsePrivateKey(ewoqKey)
+	assert.NoError(t, err)
+	addr, err := w.AddKey(sk)
+	assert.NoError(t, err)
+	assert.Equal(t, "X-local18jma8ppw3nhx5r4ap8clazz0dps7rv5u00z96u", addr)
+
+	w.Chain.ID = ids.ID{1}
+	w.Chain.AVAXAssetID = ids.ID{2}
+	w.Chain.TxFee = 1000
+	var utxos [][]byte
+	for i, amount := range amounts {
+		b, err := w.Codec().Marshal(codecVersion, &avax.UTXO{
+			UTXOID: avax.UTXOID{TxID: ids.ID{3}, OutputIndex: uint32(i)},
+			Asset:  avax.Asset{ID: w.Chain.AVAXAssetID},
+			Out: &secp256k1fx.TransferOutput{
+				Amt: amount,
+				OutputOwners: secp256k1fx.OutputOwners{
+					Threshold: 1,
+					Addrs:     []ids.ShortID{sk.PublicKey().Address()},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		utxos = append(utxos, b)
+	}
+	assert.NoError(t, w.SetUTXOs(utxos))
+	return w
+}
+
+func TestCreateTx(t *testing.T) {
+	w := testWallet(t, 5000, 7000)
+	assert.Equal(t, uint64(12000), w.Balance(w.Chain.AVAXAssetID))
+
+	to, err := w.ParseAddress("X-local1g65uqn6t77p656w64023nh8nd9updzmxyymev2")
+	assert.NoError(t, err)
+	tx, err := w.CreateTx(w.Chain.AVAXAssetID, 6000, to)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12000-6000-1000), w.Balance(w.Chain.AVAXAssetID))
+	assert.Len(t, w.UTXOs(), 1)
+
+	parsed, err := w.ParseTx(tx.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.ID(), parsed.ID())
+	assert.Equal(t, w.Chain.TxFee, Fee(parsed, w.Chain.AVAXAssetID))
+	parsed, err = ParseTx(tx.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.ID(), parsed.ID())
+
+	_, err = w.CreateTx(w.Chain.AVAXAssetID, 5000, to)
+	assert.Error(t, err)
+
+	assert.NoError(t, w.RemoveTx(parsed))
+	assert.Equal(t, uint64(12000), w.Balance(w.Chain.AVAXAssetID))
+	assert.Error(t, w.RemoveTx(parsed))
+}
+
+func TestParseAddress(t *testing.T) {
+	w := testWallet(t)
+	sk, _ := ParsePrivateKey(ewoqKey)
+	assert.Equal(t, "0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc", EthAddress(sk.PublicKey()))
+	addr, err := w.FormatChainAddress("P", sk.PublicKey().Address())
+	assert.NoError(t, err)
+	assert.Equal(t, "P-local18jma8ppw3nhx5r4ap8clazz0dps7rv5u00z96u", addr)
+	_, err = w.ParseAddress("X-avax1g65uqn6t77p656w64023nh8nd9updzmxwrd5my")
+	assert.Error(t, err)
+	_, err = w.CreateTx(w.Chain.AVAXAssetID, 1, ids.ShortEmpty)
+	assert.Error(t, err)
+}